@@ -0,0 +1,418 @@
+// Command props inspects, checks and converts the property files of an application
+//
+//	props dump [--profile dev] [--format yaml|json|properties|flat-yaml|toml|env] [--resolved|--raw]
+//	props get key
+//	props explain key
+//	props validate [--schema schema.json]
+//	props diff --profile staging --profile prod
+//	props convert in.yaml out.properties
+//	props lint [--format text|json|sarif]
+//
+// The properties are loaded from ./resources, or the directory given with --dir, just as an application using
+// DefaultProperties would load them, but without its CLI parameters. --profile takes a comma separated list of
+// profiles and may be repeated. props exits with status 1 if anything goes wrong, such as a property that can't be
+// evaluated, and 2 if it is used incorrectly
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/codesqueak/simpleProperties/pkg/simpleProperties"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// a subcommand, given its arguments and options once they have been parsed
+type command struct {
+	usage string
+	args  int // the number of arguments needed
+	flags func(fs *flag.FlagSet, o *options)
+	run   func(o *options, args []string, stdout io.Writer) error
+}
+
+// the options shared by the subcommands
+type options struct {
+	dir         string
+	profiles    profileList
+	format      string
+	raw         bool
+	resolved    bool
+	showSecrets bool
+	schema      string
+}
+
+var commands = map[string]command{
+	"dump": {
+		usage: "dump [--profile p] [--format f] [--resolved|--raw] [--show-secrets]",
+		flags: func(fs *flag.FlagSet, o *options) {
+			fs.StringVar(&o.format, "format", "properties", "the output format: properties, yaml, flat-yaml, json, toml or env")
+			fs.BoolVar(&o.resolved, "resolved", false, "write evaluated values, the default")
+			fs.BoolVar(&o.raw, "raw", false, "write values as loaded, with their ${} expressions")
+			fs.BoolVar(&o.showSecrets, "show-secrets", false, "write values of keys such as password or token")
+		},
+		run: dump,
+	},
+	"get": {
+		usage: "get [--profile p] key",
+		args:  1,
+		run:   get,
+	},
+	"explain": {
+		usage: "explain [--profile p] key",
+		args:  1,
+		run: func(o *options, args []string, stdout io.Writer) error {
+			p, err := o.load()
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(stdout, p.Explain(args[0]))
+			return nil
+		},
+	},
+	"validate": {
+		usage: "validate [--profile p] [--schema schema.json]",
+		flags: func(fs *flag.FlagSet, o *options) {
+			fs.StringVar(&o.schema, "schema", "", "a JSON Schema file the properties must match")
+		},
+		run: validate,
+	},
+	"diff": {
+		usage: "diff --profile a --profile b",
+		run:   diff,
+	},
+	"lint": {
+		usage: "lint [--format text|json|sarif]",
+		flags: func(fs *flag.FlagSet, o *options) {
+			fs.StringVar(&o.format, "format", "text", "the output format: text, json or sarif")
+		},
+		run: lint,
+	},
+	"convert": {
+		usage: "convert [--format f] in.yaml out.properties",
+		args:  2,
+		flags: func(fs *flag.FlagSet, o *options) {
+			fs.StringVar(&o.format, "format", "", "the output format, if not given by the extension of the output file, or the input file for -")
+		},
+		run: convert,
+	},
+}
+
+// run props with its arguments, returning the exit status
+func run(arguments []string, stdout io.Writer, stderr io.Writer) int {
+	if len(arguments) == 0 || arguments[0] == "help" || arguments[0] == "-h" || arguments[0] == "--help" {
+		usage(stderr)
+		return 2
+	}
+	c, found := commands[arguments[0]]
+	if !found {
+		fmt.Fprintf(stderr, "props: unknown command %s\n", arguments[0])
+		usage(stderr)
+		return 2
+	}
+	o := &options{}
+	fs := flag.NewFlagSet("props "+arguments[0], flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "usage: props %s\n", c.usage)
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&o.dir, "dir", "resources", "the directory holding the property files")
+	fs.Var(&o.profiles, "profile", "a comma separated list of profiles to load. may be repeated")
+	if c.flags != nil {
+		c.flags(fs, o)
+	}
+	args, err := parseInterspersed(fs, arguments[1:])
+	if err != nil {
+		return 2
+	}
+	if len(args) != c.args {
+		fmt.Fprintf(stderr, "props %s: expected %d arguments, got %d\n", arguments[0], c.args, len(args))
+		fs.Usage()
+		return 2
+	}
+	if err := c.run(o, args, stdout); err != nil {
+		report(stderr, err)
+		return 1
+	}
+	return 0
+}
+
+func usage(w io.Writer) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintln(w, "usage: props <command> [--dir resources] [options] [arguments]")
+	for _, name := range names {
+		fmt.Fprintf(w, "  props %s\n", commands[name].usage)
+	}
+}
+
+// parse flags wherever they appear among the arguments, so props get key --profile dev works as well as
+// props get --profile dev key. arguments after -- are never flags
+func parseInterspersed(fs *flag.FlagSet, arguments []string) ([]string, error) {
+	args := []string{}
+	for {
+		if err := fs.Parse(arguments); err != nil {
+			return nil, err
+		}
+		remaining := fs.Args()
+		if len(remaining) == 0 {
+			return args, nil
+		}
+		if len(arguments) > len(remaining) && arguments[len(arguments)-len(remaining)-1] == "--" {
+			return append(args, remaining...), nil
+		}
+		args = append(args, remaining[0])
+		arguments = remaining[1:]
+	}
+}
+
+// write an error to stderr, one line for each problem it holds
+func report(stderr io.Writer, err error) {
+	var loadError *simpleProperties.LoadError
+	if errors.As(err, &loadError) {
+		for _, e := range loadError.Errors {
+			fmt.Fprintf(stderr, "props: %v\n", e)
+		}
+		return
+	}
+	fmt.Fprintf(stderr, "props: %v\n", err)
+}
+
+// profileList the values of a repeated --profile flag, each a comma separated list of profiles
+type profileList [][]string
+
+func (l *profileList) String() string {
+	sets := []string{}
+	for _, set := range *l {
+		sets = append(sets, strings.Join(set, ","))
+	}
+	return strings.Join(sets, " ")
+}
+
+func (l *profileList) Set(value string) error {
+	set := []string{}
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			set = append(set, name)
+		}
+	}
+	if len(set) == 0 {
+		return errors.New("no profile given")
+	}
+	*l = append(*l, set)
+	return nil
+}
+
+// every profile given with --profile
+func (l profileList) all() []string {
+	all := []string{}
+	for _, set := range l {
+		all = append(all, set...)
+	}
+	return all
+}
+
+// load the properties of the resources directory, with the chosen profiles
+func (o *options) load() (*simpleProperties.Properties, error) {
+	if info, err := os.Stat(o.dir); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", o.dir)
+	}
+	p := simpleProperties.DirectoryProperties(o.dir)
+	if profiles := o.profiles.all(); len(profiles) > 0 {
+		p.SetProfiles(profiles...)
+	}
+	if o.schema != "" {
+		schema, err := simpleProperties.LoadSchemaFile(o.schema)
+		if err != nil {
+			return nil, err
+		}
+		p.SetSchema(schema)
+	}
+	return p, p.Load()
+}
+
+func dump(o *options, _ []string, stdout io.Writer) error {
+	if o.raw && o.resolved {
+		return errors.New("--raw and --resolved can't be used together")
+	}
+	format, err := parseFormat(o.format)
+	if err != nil {
+		return err
+	}
+	writeOptions := []simpleProperties.WriteOption{}
+	if o.raw {
+		writeOptions = append(writeOptions, simpleProperties.Raw())
+	}
+	if !o.showSecrets {
+		writeOptions = append(writeOptions, simpleProperties.RedactSensitive())
+	}
+	p, err := o.load()
+	if err != nil && (p == nil || !o.raw) {
+		return err // a raw dump is still useful when expressions can't be evaluated
+	}
+	return p.WriteFormat(stdout, format, writeOptions...)
+}
+
+func get(o *options, args []string, stdout io.Writer) error {
+	p, err := o.load()
+	if err != nil {
+		return err
+	}
+	key := args[0]
+	if !hasKey(p, key) {
+		return fmt.Errorf("%s is not set", key)
+	}
+	fmt.Fprintln(stdout, p.GetProperty(key))
+	return nil
+}
+
+// whether a property has a value, including an empty one
+func hasKey(p *simpleProperties.Properties, key string) bool {
+	for _, keys := range [][]string{p.GetKeys(), p.GetBootKeys()} {
+		for _, k := range keys {
+			if k == key {
+				return true
+			}
+		}
+	}
+	return p.GetProperty(key) != ""
+}
+
+// check that the properties load and evaluate, and match the schema if one is given. a property still holding an
+// expression, such as a reference to a property defined nowhere, is reported too
+func validate(o *options, _ []string, stdout io.Writer) error {
+	p, err := o.load()
+	if err != nil {
+		return err
+	}
+	unresolved := p.GetEvalKeys()
+	sort.Strings(unresolved)
+	problems := []error{}
+	for _, key := range unresolved {
+		problems = append(problems, fmt.Errorf("%s has unresolved expressions: %s", key, p.GetEvalProperty(key)))
+	}
+	if len(problems) > 0 {
+		return &simpleProperties.LoadError{Errors: problems}
+	}
+	fmt.Fprintln(stdout, "ok")
+	return nil
+}
+
+func diff(o *options, _ []string, stdout io.Writer) error {
+	if len(o.profiles) != 2 {
+		return fmt.Errorf("diff compares two sets of profiles, given with --profile a --profile b")
+	}
+	changes, err := simpleProperties.DiffProfiles(o.dir, o.profiles[0], o.profiles[1])
+	if err != nil {
+		return err
+	}
+	for _, change := range changes {
+		fmt.Fprintln(stdout, change)
+	}
+	return nil
+}
+
+// check the property files for likely mistakes, failing if any finding is worse than a note. the findings are
+// written to stdout, so that a JSON or SARIF report can be collected even when the check fails
+func lint(o *options, _ []string, stdout io.Writer) error {
+	format := simpleProperties.LintFormat(o.format)
+	if format != simpleProperties.LintText && format != simpleProperties.LintJSON && format != simpleProperties.LintSARIF {
+		return fmt.Errorf("unknown format %s", o.format)
+	}
+	findings, err := simpleProperties.Lint(o.dir)
+	if err != nil {
+		return err
+	}
+	if err := simpleProperties.WriteFindings(stdout, findings, format); err != nil {
+		return err
+	}
+	failed := 0
+	for _, f := range findings {
+		if f.Severity != simpleProperties.SeverityNote {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d problems found", failed)
+	}
+	return nil
+}
+
+// convert a single file to another format. the values are written as loaded, keeping any ${} expressions. an
+// output file of - is standard output, written in the format of the input file unless --format is given
+func convert(o *options, args []string, stdout io.Writer) error {
+	in, out := args[0], args[1]
+	name := o.format
+	if name == "" && out == "-" {
+		name = formatNames[filepath.Ext(in)]
+		if name == "" {
+			name = "properties"
+		}
+	}
+	if name == "" {
+		name = formatNames[filepath.Ext(out)]
+		if name == "" {
+			return fmt.Errorf("can't tell the format of %s, give it with --format", out)
+		}
+	}
+	format, err := parseFormat(name)
+	if err != nil {
+		return err
+	}
+	p := simpleProperties.EmptyProperties()
+	p.SetEvaluators()
+	simpleProperties.FileLoader(in)(p)
+	if err := p.Evaluate(); err != nil {
+		return err
+	}
+	if out == "-" {
+		return p.WriteFormat(stdout, format, simpleProperties.Raw())
+	}
+	file, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	if err := p.WriteFormat(file, format, simpleProperties.Raw()); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// the formats written for each output file extension
+var formatNames = map[string]string{
+	".properties": "properties",
+	".yaml":       "yaml",
+	".yml":        "yaml",
+	".json":       "json",
+	".toml":       "toml",
+	".env":        "env",
+}
+
+func parseFormat(name string) (simpleProperties.Format, error) {
+	for _, format := range []simpleProperties.Format{
+		simpleProperties.FormatProperties,
+		simpleProperties.FormatYAML,
+		simpleProperties.FormatFlatYAML,
+		simpleProperties.FormatJSON,
+		simpleProperties.FormatTOML,
+		simpleProperties.FormatEnv,
+	} {
+		if string(format) == name {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("unknown format %s", name)
+}
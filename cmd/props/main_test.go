@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		status int
+		stdout string
+		stderr string
+	}{
+		{"dump", []string{"dump", "--dir", "testdata/resources"}, 0,
+			"application.name=billing\ndb.password=******\nserver.host=localhost\nserver.port=8080\nserver.url=http://localhost:8080\n", ""},
+		{"dump raw yaml", []string{"dump", "--dir", "testdata/resources", "--format", "yaml", "--raw", "--show-secrets"}, 0,
+			"application:\n  name: billing\ndb:\n  password: s3cret\nserver:\n  host: localhost\n  port: 8080\n  url: http://${server.host}:${server.port}\n", ""},
+		{"dump profile", []string{"dump", "--dir=testdata/resources", "--profile", "prod", "--format", "env"}, 0,
+			"APPLICATION_NAME=billing\nDB_PASSWORD='******'\nSERVER_HOST=example.com\nSERVER_PORT=443\nSERVER_URL=http://example.com:443\n", ""},
+		{"dump bad format", []string{"dump", "--dir", "testdata/resources", "--format", "xml"}, 1,
+			"", "props: unknown format xml\n"},
+		{"get", []string{"get", "server.url", "--dir", "testdata/resources", "--profile", "prod"}, 0,
+			"http://example.com:443\n", ""},
+		{"get boot", []string{"get", "--dir", "testdata/resources", "application.name"}, 0, "billing\n", ""},
+		{"get missing", []string{"get", "--dir", "testdata/resources", "nothing"}, 1, "", "props: nothing is not set\n"},
+		{"get no key", []string{"get", "--dir", "testdata/resources"}, 2, "", "props get: expected 1 arguments, got 0\n"},
+		{"explain", []string{"explain", "--dir", "testdata/resources", "server.url"}, 0,
+			"server.url = http://localhost:8080\n" +
+				"  source: " + filepath.Join("testdata", "resources", "application.properties") + "\n" +
+				"  raw: http://${server.host}:${server.port}\n" +
+				"  depends on: server.host, server.port\n", ""},
+		{"validate", []string{"validate", "--dir", "testdata/resources"}, 0, "ok\n", ""},
+		{"validate broken", []string{"validate", "--dir", "testdata/resources", "--profile", "broken"}, 1, "",
+			"props: server.url has unresolved expressions: http://${server.name}\n"},
+		{"validate schema", []string{"validate", "--dir", "testdata/resources", "--profile", "prod", "--schema", "testdata/schema.json"}, 1, "",
+			"props: " + filepath.Join("testdata", "resources", "application_prod.yaml") + ": server.port: 443 is more than 100\n"},
+		{"validate missing dir", []string{"validate", "--dir", "testdata/nothing"}, 1, "", "props: testdata/nothing is not a directory\n"},
+		{"diff", []string{"diff", "--dir", "testdata/resources", "--profile", "default", "--profile", "prod"}, 0,
+			"~ server.host: localhost -> example.com (" + filepath.Join("testdata", "resources", "application_prod.yaml") + ")\n" +
+				"~ server.port: 8080 -> 443 (" + filepath.Join("testdata", "resources", "application_prod.yaml") + ")\n" +
+				"~ server.url: http://localhost:8080 -> http://example.com:443 (" + filepath.Join("testdata", "resources", "application.properties") + ")\n", ""},
+		{"diff one profile", []string{"diff", "--dir", "testdata/resources", "--profile", "prod"}, 1, "",
+			"props: diff compares two sets of profiles, given with --profile a --profile b\n"},
+		{"convert", []string{"convert", "testdata/convert.yaml", "-", "--format", "properties"}, 0,
+			"server.port=8080\nserver.url=http://${server.host}:${server.port}\n", ""},
+		{"convert to standard output", []string{"convert", "testdata/convert.yaml", "-"}, 0,
+			"server:\n  port: 8080\n  url: http://${server.host}:${server.port}\n", ""},
+		{"convert unknown output", []string{"convert", "testdata/convert.yaml", "out.xml"}, 1, "",
+			"props: can't tell the format of out.xml, give it with --format\n"},
+		{"convert missing input", []string{"convert", "testdata/missing.yaml", "-", "--format", "json"}, 1, "",
+			"props: open testdata/missing.yaml: no such file or directory\n"},
+		{"lint", []string{"lint", "--dir", "testdata/resources"}, 1,
+			filepath.Join("testdata", "resources", "application_broken.properties") + ":1: error: server.url refers to server.name, " +
+				"which is not set in any file [undefined-reference]\n" +
+				filepath.Join("testdata", "resources", "application_broken.properties") + ":1: note: no file activates profile broken, " +
+				"so it is only used if chosen on the command line, in the environment or in code [unused-profile]\n" +
+				filepath.Join("testdata", "resources", "application_prod.yaml") + ":1: note: no file activates profile prod, " +
+				"so it is only used if chosen on the command line, in the environment or in code [unused-profile]\n",
+			"props: 1 problems found\n"},
+		{"lint bad format", []string{"lint", "--dir", "testdata/resources", "--format", "xml"}, 1, "", "props: unknown format xml\n"},
+		{"unknown command", []string{"frobnicate"}, 2, "", "props: unknown command frobnicate\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+			status := run(tt.args, stdout, stderr)
+			if status != tt.status {
+				t.Errorf("status %d, want %d. stderr %s", status, tt.status, stderr)
+			}
+			if stdout.String() != tt.stdout {
+				t.Errorf("stdout\n%s\nwant\n%s", stdout, tt.stdout)
+			}
+			if !strings.HasPrefix(stderr.String(), tt.stderr) {
+				t.Errorf("stderr\n%s\nwant\n%s", stderr, tt.stderr)
+			}
+		})
+	}
+}
+
+func TestConvertFile(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "application.json")
+	stderr := &bytes.Buffer{}
+	if status := run([]string{"convert", "testdata/convert.yaml", out}, &bytes.Buffer{}, stderr); status != 0 {
+		t.Fatalf("status %d: %s", status, stderr)
+	}
+	b, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "{\n  \"server\": {\n    \"port\": \"8080\",\n    \"url\": \"http://${server.host}:${server.port}\"\n  }\n}\n"
+	if string(b) != want {
+		t.Errorf("got\n%s\nwant\n%s", b, want)
+	}
+}
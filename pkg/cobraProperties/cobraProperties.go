@@ -0,0 +1,208 @@
+// Package cobraProperties loads properties for a github.com/spf13/cobra command tree. It adds --profile and
+// --config-dir flags, binds the flags of each command to properties in the command's namespace, and loads the
+// properties before a command runs. It is kept apart so that the simpleProperties package does not depend on Cobra
+package cobraProperties
+
+import (
+	"context"
+	"strings"
+
+	"github.com/codesqueak/simpleProperties/pkg/pflagProperties"
+	"github.com/codesqueak/simpleProperties/pkg/simpleProperties"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const profileFlag = "profile"
+const configDirFlag = "config-dir"
+const defaultConfigDir = "resources"
+
+// the context key the loaded properties are kept under
+type contextKey struct{}
+
+// Binding the properties of a command tree, see Attach
+type Binding struct {
+	root       *cobra.Command
+	configDir  string
+	configure  []func(p *simpleProperties.Properties)
+	keys       map[*cobra.Command]map[string]string // flags bound to other keys with BindFlag, by command
+	properties *simpleProperties.Properties         // the last properties loaded, nil until a command runs
+}
+
+// Option changes how a Binding loads properties
+type Option func(b *Binding)
+
+// ConfigDir the resources directory used when --config-dir is not given. The default is resources
+func ConfigDir(dir string) Option {
+	return func(b *Binding) {
+		b.configDir = dir
+	}
+}
+
+// Configure a function called with the properties before they are loaded, for example to set a schema or aliases
+func Configure(f func(p *simpleProperties.Properties)) Option {
+	return func(b *Binding) {
+		b.configure = append(b.configure, f)
+	}
+}
+
+// Attach load properties for a command tree. Call it once the tree is built, as only the commands already added are
+// covered. It adds the persistent flags --profile, which can be repeated or given a list, and --config-dir to the
+// root command. Before the PreRunE of a command, the properties are loaded from the directory and profiles chosen,
+// and its flags are bound to properties in its namespace, so --port for serve sets serve.port. A flag given on the
+// command line overrides the property, and a flag default is used when no file gives it. The loaded properties
+// are returned by Get and GetProperty
+//
+//	binding := cobraProperties.Attach(rootCmd)
+//	serveCmd.Flags().Int("port", 8080, "the port to listen on")
+//	...
+//	port := cobraProperties.GetProperty(cmd, "port")   // serve.port
+func Attach(root *cobra.Command, options ...Option) *Binding {
+	b := &Binding{root: root, configDir: defaultConfigDir, keys: make(map[*cobra.Command]map[string]string)}
+	for _, option := range options {
+		option(b)
+	}
+	root.PersistentFlags().StringSlice(profileFlag, nil, "the profiles to use, such as prod or prod,metrics")
+	root.PersistentFlags().String(configDirFlag, b.configDir, "the directory holding the property files")
+	b.wrap(root)
+	return b
+}
+
+// BindFlag bind a flag of a command to a property key, rather than to the key in the command's namespace
+func (b *Binding) BindFlag(cmd *cobra.Command, flag string, key string) {
+	if b.keys[cmd] == nil {
+		b.keys[cmd] = make(map[string]string)
+	}
+	b.keys[cmd][flag] = key
+}
+
+// Properties the properties loaded for the command running, nil until one runs
+func (b *Binding) Properties() *simpleProperties.Properties {
+	return b.properties
+}
+
+// Namespace the namespace of a command's properties: the names of the commands from below the root down to it,
+// joined with dots, such as serve or db.migrate. The root command's namespace is empty
+func Namespace(cmd *cobra.Command) string {
+	names := []string{}
+	for c := cmd; c != nil && c.HasParent(); c = c.Parent() {
+		names = append([]string{c.Name()}, names...)
+	}
+	return strings.Join(names, ".")
+}
+
+// Get the properties loaded for a command, nil if it was not run by a command tree with a Binding
+func Get(cmd *cobra.Command) *simpleProperties.Properties {
+	if cmd.Context() == nil {
+		return nil
+	}
+	p, _ := cmd.Context().Value(contextKey{}).(*simpleProperties.Properties)
+	return p
+}
+
+// GetProperty get a property for a command, looking in the command's namespace first, then those of its parents,
+// then for the name itself. For serve, GetProperty(cmd, "port") is serve.port if set, otherwise port
+func GetProperty(cmd *cobra.Command, name string) string {
+	p := Get(cmd)
+	if p == nil {
+		return ""
+	}
+	for c := cmd; c != nil; c = c.Parent() {
+		if ns := Namespace(c); ns != "" {
+			if v := p.GetProperty(ns + "." + name); v != "" {
+				return v
+			}
+		}
+	}
+	return p.GetProperty(name)
+}
+
+// load the properties before the PreRunE of each command in the tree
+func (b *Binding) wrap(cmd *cobra.Command) {
+	preRunE, preRun := cmd.PreRunE, cmd.PreRun
+	cmd.PreRunE = func(c *cobra.Command, args []string) error {
+		if err := b.load(c); err != nil {
+			return err
+		}
+		if preRunE != nil {
+			return preRunE(c, args)
+		}
+		if preRun != nil { // cobra skips PreRun when there is a PreRunE
+			preRun(c, args)
+		}
+		return nil
+	}
+	for _, child := range cmd.Commands() {
+		b.wrap(child)
+	}
+}
+
+// load the properties for a command, and keep them in its context
+func (b *Binding) load(cmd *cobra.Command) error {
+	flags := cmd.Flags()
+	dir, err := flags.GetString(configDirFlag)
+	if err != nil {
+		return err
+	}
+	p := simpleProperties.DirectoryProperties(dir)
+	for _, f := range b.configure {
+		f(p)
+	}
+	if flags.Changed(profileFlag) {
+		profiles, err := flags.GetStringSlice(profileFlag)
+		if err != nil {
+			return err
+		}
+		p.SetProfiles(profiles...)
+	}
+	p.BindFlagSource(commandFlags{b, cmd})
+	if err := p.Load(); err != nil {
+		return err
+	}
+	b.properties = p
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	cmd.SetContext(context.WithValue(ctx, contextKey{}, p))
+	return nil
+}
+
+// the property key a flag of a command is bound to, or empty for the flags of the binding itself
+func (b *Binding) flagKey(cmd *cobra.Command, name string) string {
+	if name == profileFlag || name == configDirFlag || name == "help" {
+		return ""
+	}
+	for c := cmd; c != nil; c = c.Parent() {
+		if key, found := b.keys[c][name]; found {
+			return key
+		}
+		if (c == cmd && c.LocalFlags().Lookup(name) != nil) || c.PersistentFlags().Lookup(name) != nil {
+			if ns := Namespace(c); ns != "" {
+				return ns + "." + name
+			}
+			return name
+		}
+	}
+	return name
+}
+
+// the flags of a command as a FlagSource, named by the keys they are bound to
+type commandFlags struct {
+	b   *Binding
+	cmd *cobra.Command
+}
+
+func (s commandFlags) Flags() []simpleProperties.Flag {
+	flags := []simpleProperties.Flag{}
+	s.cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		key := s.b.flagKey(s.cmd, f.Name)
+		if key == "" {
+			return
+		}
+		flag := pflagProperties.Flag(f)
+		flag.Name, flag.Shorthand = key, "" // the key, not the flag name, is what the properties see
+		flags = append(flags, flag)
+	})
+	return flags
+}
@@ -0,0 +1,111 @@
+package cobraProperties
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/codesqueak/simpleProperties/pkg/simpleProperties"
+	"github.com/spf13/cobra"
+)
+
+// a command tree of app, app serve and app db migrate, recording the properties each command sees
+func newTree(got map[string]string, options ...Option) *cobra.Command {
+	root := &cobra.Command{Use: "app", SilenceUsage: true, SilenceErrors: true}
+	serve := &cobra.Command{
+		Use: "serve",
+		PreRun: func(cmd *cobra.Command, args []string) {
+			got["prerun"] = "called"
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			got["port"] = GetProperty(cmd, "port")
+			got["url"] = GetProperty(cmd, "url")
+			got["host"] = GetProperty(cmd, "server.host")
+			return nil
+		},
+	}
+	serve.Flags().IntP("port", "p", 9000, "the port to listen on")
+	db := &cobra.Command{Use: "db"}
+	db.PersistentFlags().String("schema", "public", "the database schema")
+	migrate := &cobra.Command{
+		Use: "migrate",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if Get(cmd) == nil {
+				got["prerun"] = "not loaded"
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			got["schema"] = GetProperty(cmd, "schema")
+			got["dry.run"] = Get(cmd).GetProperty("dry.run")
+			got["namespace"] = Namespace(cmd)
+			return nil
+		},
+	}
+	migrate.Flags().Bool("dry-run", false, "show the changes without making them")
+	db.AddCommand(migrate)
+	root.AddCommand(serve, db)
+
+	binding := Attach(root, append([]Option{ConfigDir("testdata/resources")}, options...)...)
+	binding.BindFlag(migrate, "dry-run", "dry.run")
+	return root
+}
+
+func TestAttach(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want map[string]string
+	}{
+		{"files", []string{"serve"},
+			map[string]string{"prerun": "called", "port": "8080", "url": "http://localhost:8080", "host": "localhost"}},
+		{"flags and profile", []string{"serve", "-p", "7000", "--profile", "prod"},
+			map[string]string{"prerun": "called", "port": "7000", "url": "http://example.com:7000", "host": "example.com"}},
+		{"config dir", []string{"serve", "--config-dir", "testdata"},
+			map[string]string{"prerun": "called", "port": "9000", "url": "", "host": ""}},
+		{"nested", []string{"db", "migrate", "--dry-run"},
+			map[string]string{"schema": "public", "dry.run": "true", "namespace": "db.migrate"}},
+		{"persistent flag", []string{"db", "migrate", "--schema", "audit"},
+			map[string]string{"schema": "audit", "dry.run": "false", "namespace": "db.migrate"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := map[string]string{}
+			root := newTree(got)
+			root.SetArgs(tt.args)
+			if err := root.Execute(); err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+			for key, value := range tt.want {
+				if got[key] != value {
+					t.Errorf("%s = %q, want %q", key, got[key], value)
+				}
+			}
+		})
+	}
+}
+
+func TestAttachLoadError(t *testing.T) {
+	schema, err := simpleProperties.LoadSchema(strings.NewReader(`{"properties": {"serve": {"properties": {
+		"port": {"type": "integer", "maximum": 100}}}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	root := newTree(got, Configure(func(p *simpleProperties.Properties) { p.SetSchema(schema) }))
+	root.SetArgs([]string{"serve"})
+	if err := root.Execute(); err == nil || !strings.Contains(err.Error(), "8080 is more than 100") {
+		t.Errorf("Execute() error = %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("the command ran after failing to load: %v", got)
+	}
+
+	root = newTree(got, Configure(func(p *simpleProperties.Properties) { p.SetSchema(schema) }))
+	root.SetArgs([]string{"serve", "--port", "80"})
+	if err := root.Execute(); err != nil {
+		t.Errorf("Execute() error = %v", err)
+	}
+}
@@ -0,0 +1,20 @@
+module github.com/codesqueak/simpleProperties/pkg/cobraProperties
+
+go 1.19
+
+require (
+	github.com/codesqueak/simpleProperties v0.0.0-00010101000000-000000000000
+	github.com/codesqueak/simpleProperties/pkg/pflagProperties v0.0.0-00010101000000-000000000000
+	github.com/spf13/cobra v1.8.1
+	github.com/spf13/pflag v1.0.5
+)
+
+require (
+	github.com/inconshreveable/mousetrap v1.1.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace (
+	github.com/codesqueak/simpleProperties => ../..
+	github.com/codesqueak/simpleProperties/pkg/pflagProperties => ../pflagProperties
+)
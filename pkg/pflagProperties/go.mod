@@ -0,0 +1,12 @@
+module github.com/codesqueak/simpleProperties/pkg/pflagProperties
+
+go 1.19
+
+require (
+	github.com/codesqueak/simpleProperties v0.0.0-00010101000000-000000000000
+	github.com/spf13/pflag v1.0.5
+)
+
+require gopkg.in/yaml.v3 v3.0.1 // indirect
+
+replace github.com/codesqueak/simpleProperties => ../..
@@ -0,0 +1,65 @@
+// Package pflagProperties binds a github.com/spf13/pflag flag set to properties, as BindFlags does for a standard
+// flag.FlagSet. It is kept apart so that the simpleProperties package does not depend on pflag
+package pflagProperties
+
+import (
+	"strings"
+
+	"github.com/codesqueak/simpleProperties/pkg/simpleProperties"
+	"github.com/spf13/pflag"
+)
+
+// Bind bind a pflag.FlagSet to properties of the same name. A flag given on the command line overrides the
+// property, and the default of a flag is used when nothing else gives the property a value. See BindFlagSource
+func Bind(p *simpleProperties.Properties, fs *pflag.FlagSet) {
+	p.BindFlagSource(Flags(fs))
+}
+
+// RegisterFlags define a string flag in a pflag.FlagSet for each of the properties, then bind the set. Each flag
+// defaults to the property's current value, except for one that looks like it holds a secret, and its usage is the
+// description given by the schema. With no keys, a flag is defined for every property. A key that is already a flag
+// is left as it is. See simpleProperties.RegisterFlags
+func RegisterFlags(p *simpleProperties.Properties, fs *pflag.FlagSet, keys ...string) {
+	for _, f := range p.PropertyFlags(keys...) {
+		if fs.Lookup(f.Name) == nil {
+			fs.String(f.Name, f.Default, f.Usage)
+		}
+	}
+	Bind(p, fs)
+}
+
+// Flags a pflag.FlagSet as a FlagSource
+func Flags(fs *pflag.FlagSet) simpleProperties.FlagSource {
+	return flagSet{fs}
+}
+
+type flagSet struct {
+	fs *pflag.FlagSet
+}
+
+func (s flagSet) Flags() []simpleProperties.Flag {
+	flags := []simpleProperties.Flag{}
+	s.fs.VisitAll(func(f *pflag.Flag) {
+		flags = append(flags, Flag(f))
+	})
+	return flags
+}
+
+// Flag a pflag.Flag as seen by properties. The values of a slice flag, such as a StringSlice, are given as a
+// comma separated list, as in a property file
+func Flag(f *pflag.Flag) simpleProperties.Flag {
+	value, defaultValue := f.Value.String(), f.DefValue
+	if slice, isSlice := f.Value.(pflag.SliceValue); isSlice {
+		value = strings.Join(slice.GetSlice(), ",")
+		defaultValue = strings.TrimSuffix(strings.TrimPrefix(defaultValue, "["), "]")
+	}
+	return simpleProperties.Flag{
+		Name:      f.Name,
+		Shorthand: f.Shorthand,
+		Usage:     f.Usage,
+		Value:     value,
+		Default:   defaultValue,
+		Set:       f.Changed,
+		IsBool:    f.NoOptDefVal != "", // a bool flag, or any other that can be given without a value
+	}
+}
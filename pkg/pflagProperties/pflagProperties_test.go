@@ -0,0 +1,78 @@
+package pflagProperties
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/codesqueak/simpleProperties/pkg/simpleProperties"
+	"github.com/spf13/pflag"
+)
+
+func TestBind(t *testing.T) {
+	args := os.Args
+	defer func() { os.Args = args }()
+	os.Args = []string{"app", "-v", "-p", "9090", "--name", "report", "input.txt", "--colour=red", "--", "--size=1"}
+
+	fs := pflag.NewFlagSet("app", pflag.ContinueOnError)
+	fs.BoolP("verbose", "v", false, "verbose")
+	fs.IntP("server.port", "p", 8080, "the port to listen on")
+	fs.String("name", "", "the report name")
+	fs.String("server.host", "localhost", "the host")
+	fs.StringSlice("tags", []string{"a", "b"}, "the tags")
+	fs.ParseErrorsWhitelist.UnknownFlags = true
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		t.Fatal(err)
+	}
+
+	p := simpleProperties.EmptyProperties()
+	p.SetEvaluators(simpleProperties.DefaultEvaluator())
+	p.Merge(map[string]string{"server.port": "80", "url": "${server.host}:${server.port}"})
+	Bind(p, fs)
+	simpleProperties.LoadCLIParameters()(p)
+	if err := p.Evaluate(); err != nil {
+		t.Fatalf("Evaluate() error = %v", err)
+	}
+	want := map[string]string{
+		"verbose":     "true",
+		"server.port": "9090",
+		"server.host": "localhost",
+		"name":        "report",
+		"tags":        "a,b",
+		"colour":      "red",
+		"url":         "localhost:9090",
+		"size":        "",
+		"v":           "",
+		"p":           "",
+	}
+	for key, value := range want {
+		if got := p.GetProperty(key); got != value {
+			t.Errorf("GetProperty(%s) = %q, want %q", key, got, value)
+		}
+	}
+}
+
+func TestRegisterFlags(t *testing.T) {
+	p := simpleProperties.EmptyProperties()
+	p.Merge(map[string]string{"server.port": "8080", "server.host": "localhost", "api.token": "t0ken"})
+	if err := p.Evaluate(); err != nil {
+		t.Fatalf("Evaluate() error = %v", err)
+	}
+	fs := pflag.NewFlagSet("app", pflag.ContinueOnError)
+	fs.IntP("server.port", "p", 80, "the port")
+	RegisterFlags(p, fs)
+	var names []string
+	fs.VisitAll(func(f *pflag.Flag) { names = append(names, f.Name+"="+f.DefValue) })
+	if !reflect.DeepEqual(names, []string{"api.token=", "server.host=localhost", "server.port=80"}) {
+		t.Errorf("flags %v", names)
+	}
+	if err := fs.Parse([]string{"--server.host", "example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Evaluate(); err != nil {
+		t.Fatalf("Evaluate() error = %v", err)
+	}
+	if got := p.GetProperty("server.host"); got != "example.com" {
+		t.Errorf("GetProperty(server.host) = %q", got)
+	}
+}
@@ -0,0 +1,128 @@
+package simpleProperties
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+)
+
+// Logger receives warnings, such as the use of a deprecated key. A *log.Logger is a Logger
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// SetLogger choose where warnings are written. By default they go to the standard logger
+func (p *Properties) SetLogger(l Logger) {
+	p.logger = l
+}
+
+// write a warning to the logger
+func (p *Properties) logf(format string, v ...interface{}) {
+	if p.logger != nil {
+		p.logger.Printf(format, v...)
+	} else {
+		log.Printf(format, v...)
+	}
+}
+
+// an old name for a property
+type alias struct {
+	key        string // the name the property has now
+	deprecated string // why the old name shouldn't be used, and what to do instead. empty if it is simply renamed
+}
+
+// AliasOption an option for Alias
+type AliasOption func(a *alias)
+
+// Deprecated warn, once, when the old name is used, with a message such as "use database.url"
+func Deprecated(message string) AliasOption {
+	return func(a *alias) {
+		if message == "" {
+			message = "use " + a.key
+		}
+		a.deprecated = message
+	}
+}
+
+// Alias map an old name for a property onto its new one, so that configuration using either can be loaded while
+// it is migrated. A value loaded or set for the old name is stored under the new one, and GetProperty finds it with
+// either name. If both names are set, Load reports the conflict. Register aliases before calling Load
+//
+//	properties.Alias("db.url", "database.url", simpleProperties.Deprecated("use database.url"))
+func (p *Properties) Alias(old string, key string, options ...AliasOption) {
+	old, key = strings.Trim(old, " \t"), strings.Trim(key, " \t")
+	if old == "" || key == "" {
+		return
+	}
+	old, key = p.relaxKey(old, ""), p.relaxKey(key, "")
+	if p.aliasTarget(key) == old {
+		p.errs = append(p.errs, fmt.Errorf("alias %s for %s would make a cycle", old, key))
+		return
+	}
+	a := &alias{key: key}
+	for _, option := range options {
+		option(a)
+	}
+	if p.aliases == nil {
+		p.aliases = make(map[string]*alias)
+	}
+	p.aliases[old] = a
+}
+
+// the name a property is stored under, following any aliases
+func (p *Properties) aliasTarget(key string) string {
+	for i := 0; i <= len(p.aliases); i++ {
+		a, found := p.aliases[key]
+		if !found {
+			break
+		}
+		key = a.key
+	}
+	return key
+}
+
+// the aliases for a property, in sorted order
+func (p *Properties) aliasesOf(key string) []string {
+	names := []string{}
+	for old := range p.aliases {
+		if old != key && p.aliasTarget(old) == key {
+			names = append(names, old)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// map a key being loaded or set onto the name it is stored under. The use of a deprecated name is logged, once,
+// and a property set with both its old and new names is reported as a conflict
+func (p *Properties) applyAlias(name string, source string) string {
+	key := p.aliasTarget(name)
+	if len(p.aliases) == 0 {
+		return key
+	}
+	if a := p.aliases[name]; a != nil && a.deprecated != "" && !p.warned[name] {
+		if p.warned == nil {
+			p.warned = make(map[string]bool)
+		}
+		p.warned[name] = true
+		p.logf("%s is deprecated: %s", describeSource(source, name), a.deprecated)
+	}
+	if earlier, found := p.setAs[key]; found && earlier != name {
+		p.errs = append(p.errs, fmt.Errorf("%s and %s are both set, and are names for %s",
+			describeSource(p.sources[key], earlier), describeSource(source, name), key))
+	}
+	if p.setAs == nil {
+		p.setAs = make(map[string]string)
+	}
+	p.setAs[key] = name
+	return key
+}
+
+// name a property with the source of its value, as describeKey does
+func describeSource(source string, key string) string {
+	if source == "" {
+		return key
+	}
+	return source + ": " + key
+}
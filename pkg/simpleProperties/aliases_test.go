@@ -0,0 +1,103 @@
+package simpleProperties
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// a Logger keeping what it is given
+type recordingLogger struct {
+	messages []string
+}
+
+func (l *recordingLogger) Printf(format string, v ...interface{}) {
+	l.messages = append(l.messages, fmt.Sprintf(format, v...))
+}
+
+func TestAlias(t *testing.T) {
+	logger := &recordingLogger{}
+	p := EmptyProperties()
+	p.SetEvaluators(DefaultEvaluator())
+	p.SetLogger(logger)
+	p.Alias("db.url", "database.url", Deprecated("use database.url"))
+	p.Alias("db.host", "database.host", Deprecated(""))
+	p.Alias("pool.size", "database.pool.size")
+	p.operations = []func(p *Properties){GlobalPropertyLoader("testdata/resources/aliases/application")}
+	err := p.Load()
+	want := []string{
+		"testdata/resources/aliases/application.yaml: database.host and " +
+			"testdata/resources/aliases/application.properties: db.host are both set, and are names for database.host",
+	}
+	if got := violations(err); !reflect.DeepEqual(got, want) {
+		t.Errorf("Load() errors %v, want %v", got, want)
+	}
+	for key, want := range map[string]string{
+		"database.url":       "postgres://db.example.com/app",
+		"db.url":             "postgres://db.example.com/app",
+		"database.host":      "db.example.com",
+		"database.pool.size": "10",
+		"pool.size":          "10",
+	} {
+		if got := p.GetProperty(key); got != want {
+			t.Errorf("GetProperty(%s) = %s, want %s", key, got, want)
+		}
+	}
+	if got := p.GetKeys(); len(got) != 3 {
+		t.Errorf("expected only the new keys to be stored, got %v", got)
+	}
+	if got := p.GetRaw("db.url"); got != "postgres://${db.host}/app" {
+		t.Errorf("GetRaw(db.url) = %s", got)
+	}
+	wantLogged := []string{
+		"testdata/resources/aliases/application.properties: db.host is deprecated: use database.host",
+		"testdata/resources/aliases/application.properties: db.url is deprecated: use database.url",
+	}
+	if len(logger.messages) != 2 || !reflect.DeepEqual(sortedCopy(logger.messages), wantLogged) {
+		t.Errorf("logged %v, want %v", logger.messages, wantLogged)
+	}
+
+	// the warning is given once, and an old name set again isn't a conflict
+	p.Set("db.url", "postgres://other/app")
+	if err := p.Evaluate(); err != nil {
+		t.Errorf("Evaluate() error = %v", err)
+	}
+	if len(logger.messages) != 2 {
+		t.Errorf("expected no more warnings, got %v", logger.messages)
+	}
+	p.Set("database.url", "postgres://new/app")
+	if err := p.Evaluate(); err == nil {
+		t.Errorf("expected a conflict setting both names")
+	}
+	p.Unset("db.url")
+	p.Set("database.url", "postgres://new/app")
+	if err := p.Evaluate(); err != nil || p.GetProperty("db.url") != "postgres://new/app" {
+		t.Errorf("after Unset, Evaluate() error = %v, value %s", err, p.GetProperty("db.url"))
+	}
+}
+
+func TestAliasChainsAndCycles(t *testing.T) {
+	p := EmptyProperties()
+	p.SetLogger(&recordingLogger{})
+	p.Alias("a", "b")
+	p.Alias("b", "c")
+	p.Alias("c", "a")
+	p.Set("a", "value")
+	if got := p.GetProperty("c"); got != "value" {
+		t.Errorf("GetProperty(c) = %s, want value", got)
+	}
+	if got := p.aliasesOf("c"); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("aliasesOf(c) = %v", got)
+	}
+	want := []string{"alias c for a would make a cycle"}
+	if got := violations(p.Evaluate()); !reflect.DeepEqual(got, want) {
+		t.Errorf("Evaluate() errors %v, want %v", got, want)
+	}
+}
+
+func sortedCopy(list []string) []string {
+	c := append([]string{}, list...)
+	sort.Strings(c)
+	return c
+}
@@ -0,0 +1,91 @@
+package simpleProperties
+
+import "sort"
+
+// GetRaw get a property as loaded or set, before evaluation, e.g. Hello ${name}. Falls back to the default
+// set for the property, then to the bootstrap properties. an alias finds the property it names
+func (p *Properties) GetRaw(key string) string {
+	key = p.storedKey(key)
+	if v, found := p.rawKeyValueMap[key]; found {
+		return v
+	}
+	if v, found := p.defaultKeyValueMap[key]; found {
+		return v
+	}
+	return p.bootKeyValueMap[key]
+}
+
+// DependsOn get the names of the properties referred to by the expressions in a property, in sorted order
+func (p *Properties) DependsOn(key string) []string {
+	if key == "" {
+		return []string{}
+	}
+	return p.references(p.GetRaw(key))
+}
+
+// Dependents get the names of the properties whose value depends on a property, either directly or through
+// other properties, in sorted order. These are the properties that change when it changes
+func (p *Properties) Dependents(key string) []string {
+	dependents := make(map[string][]string)
+	for k, v := range p.rawValues() {
+		for _, name := range p.references(v) {
+			dependents[name] = append(dependents[name], k)
+		}
+	}
+	found := make(map[string]bool)
+	queue := []string{key}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		for _, dependent := range dependents[name] {
+			if !found[dependent] {
+				found[dependent] = true
+				queue = append(queue, dependent)
+			}
+		}
+	}
+	delete(found, key) // only if it depends on itself
+	result := make([]string, 0, len(found))
+	for name := range found {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// the values of all properties as loaded or set, including defaults
+func (p *Properties) rawValues() map[string]string {
+	values := copyKV(p.defaultKeyValueMap)
+	for k, v := range p.rawKeyValueMap {
+		values[k] = v
+	}
+	return values
+}
+
+// the distinct property keys referred to by the expressions in a value, in sorted order. a name is given as the
+// key it is stored under, following aliases and relaxed keys. lookups in a namespace other than prop, such as
+// ${env:HOME}, don't refer to a property
+func (p *Properties) references(value string) []string {
+	names := []string{}
+	if !containsExpression(value) {
+		return names
+	}
+	seen := make(map[string]bool)
+	for element := extractExpressions(value).Front(); element != nil; element = element.Next() {
+		item := element.Value.(*exprParts)
+		if item.namespaced() && item.name != propNamespace {
+			if namespace, _ := p.namespace(item.name); namespace != nil {
+				continue
+			}
+		}
+		for _, name := range item.names() {
+			name = p.storedKey(name)
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
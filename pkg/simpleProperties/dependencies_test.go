@@ -0,0 +1,88 @@
+package simpleProperties
+
+import (
+	"reflect"
+	"testing"
+)
+
+func dependencyTestProperties() *Properties {
+	p := EmptyProperties()
+	p.evaluators = []func(*Properties){BasicEvaluator()}
+	p.bootKeyValueMap["application.name"] = "app"
+	p.Merge(map[string]string{
+		"host":    "localhost",
+		"port":    "8080",
+		"url":     "http://${host}:${port}/${prop:path:index}",
+		"login":   "${url}/login?next=${url}",
+		"banner":  "Welcome to ${application.name}",
+		"self":    "${self}",
+		"plain":   "no expressions",
+		"another": "${login} and ${banner}",
+	})
+	p.SetDefault("path", "${application.name}")
+	p.Evaluate()
+	return p
+}
+
+func TestGetRaw(t *testing.T) {
+	p := dependencyTestProperties()
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"url", "http://${host}:${port}/${prop:path:index}"},
+		{"host", "localhost"},
+		{"path", "${application.name}"},
+		{"application.name", "app"},
+		{"missing", ""},
+	}
+	for _, tt := range tests {
+		if got := p.GetRaw(tt.key); got != tt.want {
+			t.Errorf("GetRaw(%v) = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+	if got := p.GetProperty("url"); got != "http://localhost:8080/app" {
+		t.Errorf("GetProperty(url) = %v, want the evaluated value", got)
+	}
+}
+
+func TestDependsOn(t *testing.T) {
+	p := dependencyTestProperties()
+	tests := []struct {
+		key  string
+		want []string
+	}{
+		{"url", []string{"host", "path", "port"}},
+		{"login", []string{"url"}},
+		{"path", []string{"application.name"}},
+		{"self", []string{"self"}},
+		{"plain", []string{}},
+		{"missing", []string{}},
+		{"", []string{}},
+	}
+	for _, tt := range tests {
+		if got := p.DependsOn(tt.key); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("DependsOn(%v) = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestDependents(t *testing.T) {
+	p := dependencyTestProperties()
+	tests := []struct {
+		key  string
+		want []string
+	}{
+		{"host", []string{"another", "login", "url"}},
+		{"application.name", []string{"another", "banner", "login", "path", "url"}},
+		{"login", []string{"another"}},
+		{"another", []string{}},
+		{"self", []string{}},
+		{"missing", []string{}},
+	}
+	for _, tt := range tests {
+		if got := p.Dependents(tt.key); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Dependents(%v) = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+}
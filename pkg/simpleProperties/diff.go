@@ -0,0 +1,144 @@
+package simpleProperties
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+)
+
+// ChangeKind how a property differs between two sets of properties
+type ChangeKind string
+
+const (
+	Added   ChangeKind = "added"   // only in the second set
+	Removed ChangeKind = "removed" // only in the first set
+	Changed ChangeKind = "changed" // in both, with a different value
+)
+
+// Change a property that differs between two sets of properties, see Diff. The values of an added property are
+// empty in the first set, and those of a removed one are empty in the second
+type Change struct {
+	Key       string
+	Kind      ChangeKind
+	OldRaw    string // the value as loaded, before evaluation
+	NewRaw    string
+	OldValue  string // the value after evaluation
+	NewValue  string
+	OldSource string // where the value came from, when known
+	NewSource string
+}
+
+// String describe a change on one line, e.g. ~ server.port: 8080 -> 9090
+func (c Change) String() string {
+	switch c.Kind {
+	case Added:
+		return fmt.Sprintf("+ %s = %s%s", c.Key, c.NewValue, sourceNote(c.NewSource))
+	case Removed:
+		return fmt.Sprintf("- %s = %s%s", c.Key, c.OldValue, sourceNote(c.OldSource))
+	}
+	if c.OldValue == c.NewValue {
+		return fmt.Sprintf("~ %s: %s -> %s (both %s)%s", c.Key, c.OldRaw, c.NewRaw, c.NewValue, sourceNote(c.NewSource))
+	}
+	return fmt.Sprintf("~ %s: %s -> %s%s", c.Key, c.OldValue, c.NewValue, sourceNote(c.NewSource))
+}
+
+func sourceNote(source string) string {
+	if source == "" {
+		return ""
+	}
+	return " (" + source + ")"
+}
+
+// Diff compare two sets of properties, such as those of two profiles or two environments, giving the properties
+// added, removed or changed between a and b, in key order. A property has changed if either its evaluated value
+// or its value as loaded differs, so a change to an expression is reported even when it gives the same value.
+// Bootstrap properties are not compared
+func Diff(a *Properties, b *Properties) []Change {
+	before, after := a.diffEntries(), b.diffEntries()
+	keys := []string{}
+	for k := range before {
+		keys = append(keys, k)
+	}
+	for k := range after {
+		if _, found := before[k]; !found {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	changes := []Change{}
+	for _, k := range keys {
+		old, inA := before[k]
+		current, inB := after[k]
+		change := Change{Key: k,
+			OldRaw: old.raw, NewRaw: current.raw,
+			OldValue: old.value, NewValue: current.value,
+			OldSource: old.source, NewSource: current.source,
+		}
+		switch {
+		case !inA:
+			change.Kind = Added
+		case !inB:
+			change.Kind = Removed
+		case old.raw != current.raw || old.value != current.value:
+			change.Kind = Changed
+		default:
+			continue
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// DiffProfiles load the properties of a resources directory twice, once with each set of profiles, and compare
+// them with Diff. This shows what, say, application_prod changes relative to application_staging
+//
+//	changes, err := simpleProperties.DiffProfiles("resources", []string{"staging"}, []string{"prod"})
+func DiffProfiles(dir string, from []string, to []string) ([]Change, error) {
+	a := DirectoryProperties(dir)
+	a.SetProfiles(from...)
+	if err := a.Load(); err != nil {
+		return nil, err
+	}
+	b := DirectoryProperties(dir)
+	b.SetProfiles(to...)
+	if err := b.Load(); err != nil {
+		return nil, err
+	}
+	return Diff(a, b), nil
+}
+
+// DirectoryProperties create a properties structure as DefaultProperties does, but for a resources directory
+// other than ./resources. CLI parameters are not loaded, so the properties depend only on the files and the
+// profiles chosen with SetProfiles
+func DirectoryProperties(dir string) *Properties {
+	p := EmptyProperties()
+	p.SetEvaluators(DefaultEvaluator())
+	BootPropertyLoader(filepath.Join(dir, "bootstrap"))(p)
+	path := filepath.Join(dir, "application")
+	p.operations = []func(p *Properties){GlobalPropertyLoader(path), ProfilePropertyLoader(path)}
+	return p
+}
+
+// a property as compared by Diff
+type diffEntry struct {
+	raw    string
+	value  string
+	source string
+}
+
+// the application properties, with their values as loaded and evaluated
+func (p *Properties) diffEntries() map[string]diffEntry {
+	entries := make(map[string]diffEntry)
+	for k, v := range p.rawValues() {
+		value, found := p.keyValueMap[k]
+		if !found {
+			value = p.evalKeyValueMap[k]
+		}
+		source := p.sources[k]
+		if _, loaded := p.rawKeyValueMap[k]; !loaded {
+			source = "default"
+		}
+		entries[k] = diffEntry{raw: v, value: value, source: source}
+	}
+	return entries
+}
@@ -0,0 +1,73 @@
+package simpleProperties
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	a := EmptyProperties()
+	a.SetEvaluators(DefaultEvaluator())
+	a.Merge(map[string]string{"host": "localhost", "url": "http://${host}", "port": "8080", "debug": "true"})
+	b := EmptyProperties()
+	b.SetEvaluators(DefaultEvaluator())
+	setKVFrom(b, "host", "example.com", "application_prod.properties")
+	b.Merge(map[string]string{"url": "http://${host}", "port": "${number}", "number": "8080"})
+	b.SetDefault("timeout", "30s")
+	for _, p := range []*Properties{a, b} {
+		if err := p.Evaluate(); err != nil {
+			t.Fatalf("Evaluate() error = %v", err)
+		}
+	}
+	want := []Change{
+		{Key: "debug", Kind: Removed, OldRaw: "true", OldValue: "true"},
+		{Key: "host", Kind: Changed, OldRaw: "localhost", NewRaw: "example.com", OldValue: "localhost", NewValue: "example.com", NewSource: "application_prod.properties"},
+		{Key: "number", Kind: Added, NewRaw: "8080", NewValue: "8080"},
+		{Key: "port", Kind: Changed, OldRaw: "8080", NewRaw: "${number}", OldValue: "8080", NewValue: "8080"},
+		{Key: "timeout", Kind: Added, NewRaw: "30s", NewValue: "30s", NewSource: "default"},
+		{Key: "url", Kind: Changed, OldRaw: "http://${host}", NewRaw: "http://${host}", OldValue: "http://localhost", NewValue: "http://example.com"},
+	}
+	got := Diff(a, b)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff() = %+v, want %+v", got, want)
+	}
+	wantText := []string{
+		"- debug = true",
+		"~ host: localhost -> example.com (application_prod.properties)",
+		"+ number = 8080",
+		"~ port: 8080 -> ${number} (both 8080)",
+		"+ timeout = 30s (default)",
+		"~ url: http://localhost -> http://example.com",
+	}
+	for i, change := range got {
+		if i < len(wantText) && change.String() != wantText[i] {
+			t.Errorf("String() = %s, want %s", change, wantText[i])
+		}
+	}
+	if changes := Diff(a, a); len(changes) != 0 {
+		t.Errorf("expected no changes comparing properties with themselves, got %v", changes)
+	}
+}
+
+func TestDiffProfiles(t *testing.T) {
+	changes, err := DiffProfiles("testdata/resources/diff", []string{"staging"}, []string{"prod"})
+	if err != nil {
+		t.Fatalf("DiffProfiles() error = %v", err)
+	}
+	got := []string{}
+	for _, change := range changes {
+		got = append(got, change.String())
+	}
+	want := []string{
+		"~ db.host: db.staging -> db.prod (testdata/resources/diff/application_prod.yaml)",
+		"~ db.url: postgres://db.staging/app -> postgres://db.prod/app (testdata/resources/diff/application_prod.yaml)",
+		"- feature.beta = true (testdata/resources/diff/application_staging.properties)",
+		"~ log.level: info -> warn (testdata/resources/diff/application_prod.yaml)",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffProfiles() = %q, want %q", got, want)
+	}
+	if _, err := DiffProfiles("testdata/resources/diff", []string{"staging"}, []string{"broken"}); err != nil {
+		t.Errorf("a profile without files should load, got %v", err)
+	}
+}
@@ -0,0 +1,57 @@
+package simpleProperties
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// LoadError holds the problems found while loading properties
+type LoadError struct {
+	Errors []error
+}
+
+func (e *LoadError) Error() string {
+	if len(e.Errors) == 1 {
+		return e.Errors[0].Error()
+	}
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		messages[i] = err.Error()
+	}
+	return fmt.Sprintf("%d problems loading properties: %s", len(e.Errors), strings.Join(messages, "; "))
+}
+
+// Unwrap give the individual errors
+func (e *LoadError) Unwrap() []error {
+	return e.Errors
+}
+
+// Is report whether any of the individual errors matches target, for errors.Is. Go releases before 1.20 don't
+// look through Unwrap() []error themselves
+func (e *LoadError) Is(target error) bool {
+	for _, err := range e.Errors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As find the first of the individual errors that matches target, for errors.As
+func (e *LoadError) As(target interface{}) bool {
+	for _, err := range e.Errors {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// combine a list of errors into a *LoadError, or nil if there are none
+func newLoadError(errs []error) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return &LoadError{append([]error{}, errs...)}
+}
@@ -0,0 +1,32 @@
+package simpleProperties
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+)
+
+func TestLoadErrorIsAs(t *testing.T) {
+	err := newLoadError([]error{
+		errors.New("first"),
+		&fs.PathError{Op: "open", Path: "missing.yaml", Err: fs.ErrNotExist},
+		&Violation{Key: "server.port", Message: "is required"},
+	})
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("errors.Is(%v, fs.ErrNotExist) = false", err)
+	}
+	if errors.Is(err, fs.ErrPermission) {
+		t.Errorf("errors.Is(%v, fs.ErrPermission) = true", err)
+	}
+	var violation *Violation
+	if !errors.As(err, &violation) || violation.Key != "server.port" {
+		t.Errorf("errors.As(%v, *Violation) = %v", err, violation)
+	}
+	var pathError *fs.PathError
+	if !errors.As(err, &pathError) || pathError.Path != "missing.yaml" {
+		t.Errorf("errors.As(%v, *fs.PathError) = %v", err, pathError)
+	}
+	if newLoadError(nil) != nil {
+		t.Errorf("newLoadError(nil) is not nil")
+	}
+}
@@ -0,0 +1,125 @@
+package simpleProperties
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Evaluator evaluates the expressions held in property values, once loading is complete. An evaluator sees the
+// properties only through an EvaluationContext, so it needs no access to how they are stored
+type Evaluator interface {
+	Evaluate(c EvaluationContext)
+}
+
+// EvaluatorFunc lets an ordinary function be used as an Evaluator
+type EvaluatorFunc func(c EvaluationContext)
+
+// Evaluate call f(c)
+func (f EvaluatorFunc) Evaluate(c EvaluationContext) {
+	f(c)
+}
+
+// EvaluationContext the properties as seen by an Evaluator
+type EvaluationContext interface {
+	// Keys every property key, including bootstrap properties and those still to be evaluated, in sorted order
+	Keys() []string
+	// Unresolved the keys whose values hold ${} expressions still to be evaluated, in sorted order
+	Unresolved() []string
+	// Raw the text of an unresolved property, with its expressions in place
+	Raw(key string) string
+	// Lookup the value of a property, as GetProperty gives it, and whether it has one. a property set to an empty
+	// string has a value, an unresolved property does not
+	Lookup(key string) (string, bool)
+	// Key the key a property is stored under, for a name an expression refers to it by. this differs from the name
+	// for an alias, or with relaxed keys for another spelling of the key
+	Key(name string) string
+	// Resolve set the evaluated value of a property. if the value still holds ${} expressions the property stays
+	// unresolved, with the new text, for a later evaluator to finish
+	Resolve(key string, value string)
+	// Namespace the resolver for a namespace, such as env in ${env:HOME}. An error if the name is not a namespace,
+	// unless namespaces are lenient, when it is nil with no error, so ${name:value} is a property with a default
+	Namespace(name string) (Resolver, error)
+	// Fail report a problem evaluating a property, returned by Load or Evaluate. key may be empty for a problem
+	// not tied to one property
+	Fail(key string, err error)
+}
+
+// SetEvaluators choose the evaluators run, in order, once properties are loaded. With no evaluators, values are
+// left as loaded and properties holding expressions stay unresolved
+func (p *Properties) SetEvaluators(evaluators ...Evaluator) {
+	p.evaluators = make([]func(p *Properties), 0, len(evaluators))
+	for _, e := range evaluators {
+		p.evaluators = append(p.evaluators, evaluatorOperation(e))
+	}
+}
+
+// DefaultEvaluator the evaluator for ${} expressions used by DefaultProperties. See BasicEvaluator for how
+// references are resolved
+func DefaultEvaluator() Evaluator {
+	return EvaluatorFunc(resolveReferences)
+}
+
+// NoOpEvaluator an evaluator that changes nothing, leaving every expression unresolved
+func NoOpEvaluator() Evaluator {
+	return EvaluatorFunc(func(EvaluationContext) {})
+}
+
+// run an evaluator as one of the operations on a properties structure
+func evaluatorOperation(e Evaluator) func(p *Properties) {
+	return func(p *Properties) {
+		e.Evaluate(&evaluationContext{p: p})
+	}
+}
+
+// the EvaluationContext for a properties structure
+type evaluationContext struct {
+	p *Properties
+}
+
+func (c *evaluationContext) Keys() []string {
+	seen := make(map[string]bool)
+	keys := []string{}
+	for _, m := range []map[string]string{c.p.bootKeyValueMap, c.p.keyValueMap, c.p.evalKeyValueMap} {
+		for k := range m {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (c *evaluationContext) Unresolved() []string {
+	keys := c.p.GetEvalKeys()
+	sort.Strings(keys)
+	return keys
+}
+
+func (c *evaluationContext) Raw(key string) string {
+	return c.p.evalKeyValueMap[key]
+}
+
+func (c *evaluationContext) Lookup(key string) (string, bool) {
+	return c.p.lookup(key)
+}
+
+func (c *evaluationContext) Key(name string) string {
+	return c.p.storedKey(name)
+}
+
+func (c *evaluationContext) Resolve(key string, value string) {
+	storeKV(c.p, key, value)
+}
+
+func (c *evaluationContext) Namespace(name string) (Resolver, error) {
+	return c.p.namespace(name)
+}
+
+func (c *evaluationContext) Fail(key string, err error) {
+	if key != "" {
+		err = fmt.Errorf("%s: %w", c.p.describeKey(key), err)
+	}
+	c.p.errs = append(c.p.errs, err)
+}
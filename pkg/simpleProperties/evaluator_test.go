@@ -0,0 +1,90 @@
+package simpleProperties
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSetEvaluators(t *testing.T) {
+	newProperties := func() *Properties {
+		p := EmptyProperties()
+		p.bootKeyValueMap["boot"] = "B"
+		p.Merge(map[string]string{"name": "world", "greeting": "hello ${name}", "shout": "${greeting} ${upper:name}"})
+		return p
+	}
+	// replaces ${upper:key} with the value of key in upper case, leaving other expressions for a later evaluator
+	upper := EvaluatorFunc(func(c EvaluationContext) {
+		for _, key := range c.Unresolved() {
+			raw := c.Raw(key)
+			for _, e := range scanExpressions(raw) {
+				if strings.HasPrefix(e.content, "upper:") {
+					v, _ := c.Lookup(strings.TrimPrefix(e.content, "upper:"))
+					raw = strings.Replace(raw, e.full, strings.ToUpper(v), 1)
+				}
+			}
+			c.Resolve(key, raw)
+		}
+	})
+
+	t.Run("default", func(t *testing.T) {
+		p := newProperties()
+		p.SetEvaluators(DefaultEvaluator())
+		// on its own, the default evaluator doesn't know upper
+		if err := p.Evaluate(); err == nil || !strings.Contains(err.Error(), "unknown namespace upper") {
+			t.Fatalf("Evaluate() error = %v", err)
+		}
+		if got := p.GetProperty("greeting"); got != "hello world" {
+			t.Errorf("GetProperty(greeting) = %v", got)
+		}
+	})
+
+	t.Run("no-op", func(t *testing.T) {
+		p := newProperties()
+		p.SetEvaluators(NoOpEvaluator())
+		if err := p.Evaluate(); err != nil {
+			t.Fatalf("Evaluate() error = %v", err)
+		}
+		if got := p.GetProperty("greeting"); got != "" {
+			t.Errorf("GetProperty(greeting) = %v, want nothing", got)
+		}
+		if got := p.GetEvalProperty("greeting"); got != "hello ${name}" {
+			t.Errorf("GetEvalProperty(greeting) = %v", got)
+		}
+	})
+
+	t.Run("in order", func(t *testing.T) {
+		p := newProperties()
+		p.SetEvaluators(upper, DefaultEvaluator())
+		if err := p.Evaluate(); err != nil {
+			t.Fatalf("Evaluate() error = %v", err)
+		}
+		if got := p.GetProperty("shout"); got != "hello world WORLD" {
+			t.Errorf("GetProperty(shout) = %v", got)
+		}
+		if got := p.GetRaw("shout"); got != "${greeting} ${upper:name}" {
+			t.Errorf("GetRaw(shout) = %v", got)
+		}
+	})
+
+	t.Run("context", func(t *testing.T) {
+		p := newProperties()
+		var keys, unresolved []string
+		p.SetEvaluators(EvaluatorFunc(func(c EvaluationContext) {
+			keys, unresolved = c.Keys(), c.Unresolved()
+			c.Fail("shout", errors.New("too loud"))
+			c.Fail("", errors.New("general"))
+		}))
+		err := p.Evaluate()
+		if !reflect.DeepEqual(keys, []string{"boot", "greeting", "name", "shout"}) {
+			t.Errorf("Keys() = %v", keys)
+		}
+		if !reflect.DeepEqual(unresolved, []string{"greeting", "shout"}) {
+			t.Errorf("Unresolved() = %v", unresolved)
+		}
+		if err == nil || err.Error() != "2 problems loading properties: shout: too loud; general" {
+			t.Errorf("Evaluate() error = %v", err)
+		}
+	})
+}
@@ -0,0 +1,77 @@
+package simpleProperties
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Explain describe where a property's value comes from: its value, the file or other source that set it, the value
+// as loaded when it holds expressions, any default, the properties it depends on and its aliases. An alias is
+// explained as the property it names
+//
+//	database.url = postgres://db.example.com/app
+//	  source: config/application.properties, as db.url
+//	  raw: postgres://${db.host}/app
+//	  depends on: db.host
+//	  aliases: db.url (deprecated: use database.url)
+func (p *Properties) Explain(key string) string {
+	lines := []string{}
+	name := p.relaxedName(strings.Trim(key, " \t"))
+	key = p.aliasTarget(name)
+	if key != name {
+		line := fmt.Sprintf("%s is an alias for %s", name, key)
+		if a := p.aliases[name]; a.deprecated != "" {
+			line += fmt.Sprintf(" (deprecated: %s)", a.deprecated)
+		}
+		lines = append(lines, line)
+	}
+	raw, loaded := p.rawKeyValueMap[key]
+	defaultValue, hasDefault := p.defaultKeyValueMap[key]
+	_, boot := p.bootKeyValueMap[key]
+	_, unresolved := p.evalKeyValueMap[key]
+	v, found := p.lookup(key)
+	switch {
+	case unresolved:
+		lines = append(lines, fmt.Sprintf("%s is unresolved", key))
+	case found:
+		lines = append(lines, fmt.Sprintf("%s = %s", key, v))
+	default:
+		lines = append(lines, fmt.Sprintf("%s is not set", key))
+	}
+	switch {
+	case loaded:
+		source := p.sources[key]
+		if source == "" {
+			source = "set in code"
+		}
+		if as := p.setAs[key]; as != "" && as != key {
+			source += ", as " + as
+		}
+		lines = append(lines, "  source: "+source)
+	case hasDefault:
+		lines = append(lines, "  source: default")
+	case boot:
+		lines = append(lines, "  source: bootstrap properties")
+	}
+	if loaded && raw != v || !loaded && hasDefault && defaultValue != v {
+		lines = append(lines, "  raw: "+p.GetRaw(key))
+	}
+	if loaded && hasDefault {
+		lines = append(lines, "  default: "+defaultValue)
+	}
+	if dependsOn := p.DependsOn(key); len(dependsOn) > 0 {
+		lines = append(lines, "  depends on: "+strings.Join(dependsOn, ", "))
+	}
+	if rule, found := p.schema[key]; found && rule.description != "" {
+		lines = append(lines, "  description: "+rule.description)
+	}
+	if aliases := p.aliasesOf(key); len(aliases) > 0 {
+		for i, old := range aliases {
+			if deprecated := p.aliases[old].deprecated; deprecated != "" {
+				aliases[i] = fmt.Sprintf("%s (deprecated: %s)", old, deprecated)
+			}
+		}
+		lines = append(lines, "  aliases: "+strings.Join(aliases, ", "))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,56 @@
+package simpleProperties
+
+import "testing"
+
+func TestExplain(t *testing.T) {
+	p := EmptyProperties()
+	p.SetEvaluators(DefaultEvaluator())
+	p.SetLogger(&recordingLogger{})
+	p.Alias("db.url", "database.url", Deprecated("use database.url"))
+	p.SetSchema(Schema{"database.url": URL().Description("where the data lives")})
+	p.bootKeyValueMap["application.name"] = "billing"
+	setKVFrom(p, "db.url", "postgres://${db.host}/app", "config/application.properties")
+	setKVFrom(p, "db.host", "db.example.com", "config/application.properties")
+	p.Set("retries", "${missing}")
+	p.SetDefault("timeout", "30s")
+	p.SetDefault("db.host", "localhost")
+	_ = p.Evaluate()
+
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"database.url", `database.url = postgres://db.example.com/app
+  source: config/application.properties, as db.url
+  raw: postgres://${db.host}/app
+  depends on: db.host
+  description: where the data lives
+  aliases: db.url (deprecated: use database.url)`},
+		{"db.url", `db.url is an alias for database.url (deprecated: use database.url)
+database.url = postgres://db.example.com/app
+  source: config/application.properties, as db.url
+  raw: postgres://${db.host}/app
+  depends on: db.host
+  description: where the data lives
+  aliases: db.url (deprecated: use database.url)`},
+		{"db.host", `db.host = db.example.com
+  source: config/application.properties
+  default: localhost`},
+		{"retries", `retries is unresolved
+  source: set in code
+  raw: ${missing}
+  depends on: missing`},
+		{"timeout", `timeout = 30s
+  source: default`},
+		{"application.name", `application.name = billing
+  source: bootstrap properties`},
+		{"nothing", `nothing is not set`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if got := p.Explain(tt.key); got != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,833 @@
+package simpleProperties
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Expressions inside ${} are either a plain reference, ${name} or ${prop:name:default}, or use a small expression
+// language:
+//
+//	${feature.enabled ? https : http}     choose a value
+//	${if:profile==prod:strict:relaxed}    the same, written without spaces
+//	${a ?: b ?: literal}                  the first of a and b with a value, else literal
+//	${a == b}, ${a != b}, ${a < b} ...    comparison, numeric when both sides are numbers
+//	${a && !b || (c)}                     boolean logic
+//	${cpu.count * 4}, ${a + b}            arithmetic with + - * / %, where + joins text that is not a number
+//	${region | lower}                     filters: lower, upper, trim, quote and default "value"
+//
+// A bare word names a property. When no property of that name has a value the word stands for itself, so in
+// ${mode ? https : http} the words https and http are plain text unless properties of those names exist.
+// Quoted strings, "text" or 'text', are always plain text. A word with no value counts as false in a condition
+// and is skipped by ?:. The values "", "false", "0", "no" and "off" are also false. A - inside a word is part of
+// the name, so subtraction needs spaces: ${max-pool - 1}
+//
+// Evaluating an expression only reads properties, it never changes them
+
+// a scanned ${} expression, with the position of the text it covers
+type scannedExpression struct {
+	full    string // the whole expression, such as ${a ? b : c}
+	content string // the text between the braces
+}
+
+// find each ${} expression in a value. a plain reference ends at the first }, otherwise braces are matched and
+// quoted text skipped, so that } can appear in a string
+func scanExpressions(value string) []scannedExpression {
+	found := []scannedExpression{}
+	for start := 0; start < len(value); {
+		open := strings.Index(value[start:], "${")
+		if open < 0 {
+			break
+		}
+		open += start
+		end := strings.IndexByte(value[open+2:], '}') + open + 2
+		if end < open+2 || !isPlainReference(value[open+2:end]) {
+			end = matchBrace(value, open+2)
+		}
+		if end < 0 || end == open+2 {
+			start = open + 2 // unterminated or empty, so plain text
+			continue
+		}
+		found = append(found, scannedExpression{full: value[open : end+1], content: value[open+2 : end]})
+		start = end + 1
+	}
+	return found
+}
+
+// the position of the } closing an expression whose content starts at from, or -1 if there isn't one
+func matchBrace(value string, from int) int {
+	depth := 0
+	var quote byte
+	for i := from; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '{':
+			depth++
+		case c == '}':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		}
+	}
+	return -1
+}
+
+// characters that make the name part of an expression an operator expression rather than a plain reference
+const operatorCharacters = "?!=<>&|()\"'+*/%"
+
+// whether the content of a ${} expression is a plain ${name} or ${name:default} reference. the default may hold
+// any text, including spaces
+func isPlainReference(content string) bool {
+	name, _, _ := strings.Cut(content, ":")
+	return name != "" && name != "if" && !strings.ContainsAny(name, " \t\r\n"+operatorCharacters)
+}
+
+// parse the content of a ${} expression. plain references keep the original ${name:default} meaning, where
+// the default is everything after the first colon
+func parseExpression(full string, content string) *exprParts {
+	parts := &exprParts{full: full}
+	name, defaultValue, hasDefault := strings.Cut(content, ":")
+	switch {
+	case isPlainReference(content):
+		parts.name = name
+		parts.defaultValue = defaultValue
+		return parts
+	case name == "if" && hasDefault:
+		parts.expr, parts.err = parseIf(defaultValue)
+	default:
+		parts.expr, parts.err = parseOperators(content)
+	}
+	if parts.err != nil {
+		parts.err = fmt.Errorf("expression %s: %w", full, parts.err)
+	}
+	return parts
+}
+
+// parse ${if:condition:then:else}, where each part is itself an expression
+func parseIf(content string) (exprNode, error) {
+	fields := splitTopLevel(content, ':')
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("if needs a condition, a value and an alternative separated by ':'")
+	}
+	nodes := make([]exprNode, 3)
+	for i, field := range fields {
+		node, err := parseOperators(field)
+		if err != nil {
+			return nil, err
+		}
+		nodes[i] = node
+	}
+	return &conditionalNode{condition: nodes[0], then: nodes[1], otherwise: nodes[2]}, nil
+}
+
+// split text at a separator, ignoring separators in quoted strings or parentheses
+func splitTopLevel(s string, separator byte) []string {
+	fields := []string{}
+	depth, start := 0, 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == separator && depth == 0:
+			fields = append(fields, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(fields, s[start:])
+}
+
+// parse an expression written with operators
+func parseOperators(content string) (exprNode, error) {
+	tokens, err := tokenise(content)
+	if err != nil {
+		return nil, err
+	}
+	ep := &exprParser{tokens: tokens}
+	node, err := ep.pipeline()
+	if err != nil {
+		return nil, err
+	}
+	if t := ep.peek(); t.kind != tokenEnd {
+		return nil, fmt.Errorf("unexpected %s at offset %d", t, t.offset)
+	}
+	return node, nil
+}
+
+const (
+	tokenEnd = iota
+	tokenWord
+	tokenString
+	tokenOperator
+)
+
+type exprToken struct {
+	kind   int
+	text   string
+	offset int
+}
+
+func (t exprToken) String() string {
+	switch t.kind {
+	case tokenEnd:
+		return "end of expression"
+	case tokenString:
+		return strconv.Quote(t.text)
+	}
+	return "'" + t.text + "'"
+}
+
+// operators, longest first so that ?: is found before ?
+var exprOperators = []string{"?:", "==", "!=", "<=", ">=", "&&", "||", "?", ":", "!", "<", ">", "(", ")", "|", "+", "*",
+	"/", "%"}
+
+// split an expression into words, quoted strings and operators
+func tokenise(s string) ([]exprToken, error) {
+	tokens := []exprToken{}
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			i++
+		case c == '-' && (i+1 == len(s) || s[i+1] < '0' || s[i+1] > '9' || followsValue(tokens)):
+			// a - inside a word is part of a property name, so max-pool is one word and max - pool a subtraction.
+			// at the start of a word it is an operator, unless it begins a negative number
+			tokens = append(tokens, exprToken{kind: tokenOperator, text: "-", offset: i})
+			i++
+		case c == '"' || c == '\'':
+			text, n, err := readString(s[i:])
+			if err != nil {
+				return nil, fmt.Errorf("%w at offset %d", err, i)
+			}
+			tokens = append(tokens, exprToken{kind: tokenString, text: text, offset: i})
+			i += n
+		default:
+			operator := ""
+			for _, op := range exprOperators {
+				if strings.HasPrefix(s[i:], op) {
+					operator = op
+					break
+				}
+			}
+			if operator != "" {
+				tokens = append(tokens, exprToken{kind: tokenOperator, text: operator, offset: i})
+				i += len(operator)
+				continue
+			}
+			start := i
+			for i < len(s) && !strings.ContainsRune(" \t\r\n:"+operatorCharacters, rune(s[i])) {
+				i++
+			}
+			if i == start {
+				return nil, fmt.Errorf("unexpected '%c' at offset %d", c, i)
+			}
+			tokens = append(tokens, exprToken{kind: tokenWord, text: s[start:i], offset: start})
+		}
+	}
+	return append(tokens, exprToken{kind: tokenEnd, offset: len(s)}), nil
+}
+
+// whether the last token ends a value, so that a following - subtracts rather than starting a negative number
+func followsValue(tokens []exprToken) bool {
+	if len(tokens) == 0 {
+		return false
+	}
+	last := tokens[len(tokens)-1]
+	return last.kind == tokenWord || last.kind == tokenString || last.text == ")"
+}
+
+// read a quoted string from the start of s, returning its text and the number of bytes used
+func readString(s string) (string, int, error) {
+	quote := s[0]
+	text := strings.Builder{}
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == quote:
+			return text.String(), i + 1, nil
+		case c == '\\' && i+1 < len(s):
+			i++
+			text.WriteByte(s[i])
+		default:
+			text.WriteByte(c)
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string")
+}
+
+// recursive descent parser, lowest precedence first
+//
+//	pipeline = ternary { "|" filter { primary } }
+//	ternary  = coalesce [ "?" pipeline ":" ternary ]
+//	coalesce = or { "?:" or }
+//	or       = and { "||" and }
+//	and      = compare { "&&" compare }
+//	compare  = sum [ ( "==" | "!=" | "<" | "<=" | ">" | ">=" ) sum ]
+//	sum      = product { ( "+" | "-" ) product }
+//	product  = unary { ( "*" | "/" | "%" ) unary }
+//	unary    = ( "!" | "-" ) unary | primary
+//	primary  = "(" pipeline ")" | word | number | string
+type exprParser struct {
+	tokens   []exprToken
+	position int
+}
+
+func (ep *exprParser) peek() exprToken {
+	return ep.tokens[ep.position]
+}
+
+// consume the next token if it is one of the operators given
+func (ep *exprParser) accept(operators ...string) (string, bool) {
+	t := ep.peek()
+	if t.kind != tokenOperator {
+		return "", false
+	}
+	for _, op := range operators {
+		if t.text == op {
+			ep.position++
+			return op, true
+		}
+	}
+	return "", false
+}
+
+func (ep *exprParser) expect(operator string) error {
+	if _, found := ep.accept(operator); !found {
+		t := ep.peek()
+		return fmt.Errorf("expected '%s' but found %s at offset %d", operator, t, t.offset)
+	}
+	return nil
+}
+
+// parse a left associative sequence of binary operators
+func (ep *exprParser) binary(next func() (exprNode, error), operators ...string) (exprNode, error) {
+	node, err := next()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		operator, found := ep.accept(operators...)
+		if !found {
+			return node, nil
+		}
+		right, err := next()
+		if err != nil {
+			return nil, err
+		}
+		switch operator {
+		case "?:":
+			node = &coalesceNode{first: node, second: right}
+		case "&&", "||":
+			node = &logicalNode{operator: operator, left: node, right: right}
+		default:
+			node = &arithmeticNode{operator: operator, left: node, right: right}
+		}
+	}
+}
+
+func (ep *exprParser) pipeline() (exprNode, error) {
+	node, err := ep.ternary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, found := ep.accept("|"); !found {
+			return node, nil
+		}
+		t := ep.peek()
+		if t.kind != tokenWord {
+			return nil, fmt.Errorf("expected a filter but found %s at offset %d", t, t.offset)
+		}
+		filter, known := exprFilters[t.text]
+		if !known {
+			return nil, fmt.Errorf("unknown filter '%s' at offset %d", t.text, t.offset)
+		}
+		ep.position++
+		arguments := []exprNode{}
+		for len(arguments) < filter.arguments {
+			argument, err := ep.primary()
+			if err != nil {
+				return nil, fmt.Errorf("filter %s: %w", t.text, err)
+			}
+			arguments = append(arguments, argument)
+		}
+		node = &filterNode{name: t.text, operand: node, arguments: arguments}
+	}
+}
+
+func (ep *exprParser) ternary() (exprNode, error) {
+	condition, err := ep.coalesce()
+	if err != nil {
+		return nil, err
+	}
+	if _, found := ep.accept("?"); !found {
+		return condition, nil
+	}
+	then, err := ep.pipeline()
+	if err != nil {
+		return nil, err
+	}
+	if err := ep.expect(":"); err != nil {
+		return nil, err
+	}
+	otherwise, err := ep.ternary()
+	if err != nil {
+		return nil, err
+	}
+	return &conditionalNode{condition: condition, then: then, otherwise: otherwise}, nil
+}
+
+func (ep *exprParser) coalesce() (exprNode, error) {
+	return ep.binary(ep.or, "?:")
+}
+
+func (ep *exprParser) or() (exprNode, error) {
+	return ep.binary(ep.and, "||")
+}
+
+func (ep *exprParser) and() (exprNode, error) {
+	return ep.binary(ep.compare, "&&")
+}
+
+func (ep *exprParser) compare() (exprNode, error) {
+	node, err := ep.sum()
+	if err != nil {
+		return nil, err
+	}
+	operator, found := ep.accept("==", "!=", "<=", ">=", "<", ">")
+	if !found {
+		return node, nil
+	}
+	right, err := ep.sum()
+	if err != nil {
+		return nil, err
+	}
+	return &compareNode{operator: operator, left: node, right: right}, nil
+}
+
+func (ep *exprParser) sum() (exprNode, error) {
+	return ep.binary(ep.product, "+", "-")
+}
+
+func (ep *exprParser) product() (exprNode, error) {
+	return ep.binary(ep.unary, "*", "/", "%")
+}
+
+func (ep *exprParser) unary() (exprNode, error) {
+	operator, found := ep.accept("!", "-")
+	if !found {
+		return ep.primary()
+	}
+	node, err := ep.unary()
+	if err != nil {
+		return nil, err
+	}
+	if operator == "-" {
+		return &arithmeticNode{operator: operator, left: &literalNode{text: "0"}, right: node}, nil
+	}
+	return &notNode{operand: node}, nil
+}
+
+func (ep *exprParser) primary() (exprNode, error) {
+	if _, found := ep.accept("("); found {
+		node, err := ep.pipeline()
+		if err != nil {
+			return nil, err
+		}
+		if err := ep.expect(")"); err != nil {
+			return nil, err
+		}
+		return node, nil
+	}
+	t := ep.peek()
+	switch t.kind {
+	case tokenWord:
+		ep.position++
+		if isNumber(t.text) {
+			return &literalNode{text: t.text}, nil
+		}
+		return &wordNode{name: t.text}, nil
+	case tokenString:
+		ep.position++
+		return &literalNode{text: t.text}, nil
+	}
+	return nil, fmt.Errorf("expected a value but found %s at offset %d", t, t.offset)
+}
+
+// whether a word is a number, such as 8080, -1 or 0.5, rather than a property name
+func isNumber(word string) bool {
+	digits, point := 0, false
+	for i, c := range word {
+		switch {
+		case c >= '0' && c <= '9':
+			digits++
+		case c == '-' && i == 0:
+		case c == '.' && !point && digits > 0:
+			point = true
+		default:
+			return false
+		}
+	}
+	return digits > 0 && word[len(word)-1] != '.'
+}
+
+// the result of evaluating part of an expression. a word naming no property with a value is not defined, but
+// still has its own text
+type exprValue struct {
+	text    string
+	defined bool
+	name    string // the property the value came from, for error messages
+}
+
+// a defined value counts as true unless it is one of the usual ways of writing false
+func (v exprValue) truth() bool {
+	if !v.defined {
+		return false
+	}
+	switch strings.ToLower(v.text) {
+	case "", "false", "0", "no", "off":
+		return false
+	}
+	return true
+}
+
+// describe a value for an error message
+func (v exprValue) String() string {
+	switch {
+	case v.name == "":
+		return strconv.Quote(v.text)
+	case v.defined:
+		return fmt.Sprintf("%s (%q)", v.name, v.text)
+	}
+	return v.name + " (no value)"
+}
+
+func boolValue(b bool) exprValue {
+	return exprValue{text: strconv.FormatBool(b), defined: true}
+}
+
+// find the value of a property, reporting whether it has one
+type exprLookup func(name string) (string, bool)
+
+// a node of a parsed expression
+type exprNode interface {
+	eval(lookup exprLookup) (exprValue, error)
+	names(add func(name string)) // the property names the node refers to
+}
+
+type wordNode struct {
+	name string
+}
+
+func (n *wordNode) eval(lookup exprLookup) (exprValue, error) {
+	if v, found := lookup(n.name); found {
+		return exprValue{text: v, defined: true, name: n.name}, nil
+	}
+	return exprValue{text: n.name, name: n.name}, nil
+}
+
+func (n *wordNode) names(add func(string)) {
+	add(n.name)
+}
+
+type literalNode struct {
+	text string
+}
+
+func (n *literalNode) eval(exprLookup) (exprValue, error) {
+	return exprValue{text: n.text, defined: true}, nil
+}
+
+func (n *literalNode) names(func(string)) {}
+
+type conditionalNode struct {
+	condition, then, otherwise exprNode
+}
+
+func (n *conditionalNode) eval(lookup exprLookup) (exprValue, error) {
+	condition, err := n.condition.eval(lookup)
+	if err != nil {
+		return exprValue{}, err
+	}
+	if condition.truth() {
+		return n.then.eval(lookup)
+	}
+	return n.otherwise.eval(lookup)
+}
+
+func (n *conditionalNode) names(add func(string)) {
+	n.condition.names(add)
+	n.then.names(add)
+	n.otherwise.names(add)
+}
+
+type coalesceNode struct {
+	first, second exprNode
+}
+
+func (n *coalesceNode) eval(lookup exprLookup) (exprValue, error) {
+	v, err := n.first.eval(lookup)
+	if err != nil || v.defined {
+		return v, err
+	}
+	return n.second.eval(lookup)
+}
+
+func (n *coalesceNode) names(add func(string)) {
+	n.first.names(add)
+	n.second.names(add)
+}
+
+type logicalNode struct {
+	operator    string
+	left, right exprNode
+}
+
+func (n *logicalNode) eval(lookup exprLookup) (exprValue, error) {
+	left, err := n.left.eval(lookup)
+	if err != nil {
+		return exprValue{}, err
+	}
+	if left.truth() == (n.operator == "||") {
+		return boolValue(left.truth()), nil
+	}
+	right, err := n.right.eval(lookup)
+	if err != nil {
+		return exprValue{}, err
+	}
+	return boolValue(right.truth()), nil
+}
+
+func (n *logicalNode) names(add func(string)) {
+	n.left.names(add)
+	n.right.names(add)
+}
+
+type notNode struct {
+	operand exprNode
+}
+
+func (n *notNode) eval(lookup exprLookup) (exprValue, error) {
+	v, err := n.operand.eval(lookup)
+	if err != nil {
+		return exprValue{}, err
+	}
+	return boolValue(!v.truth()), nil
+}
+
+func (n *notNode) names(add func(string)) {
+	n.operand.names(add)
+}
+
+type compareNode struct {
+	operator    string
+	left, right exprNode
+}
+
+// compare numerically when both sides are numbers, otherwise compare the text
+func (n *compareNode) eval(lookup exprLookup) (exprValue, error) {
+	left, err := n.left.eval(lookup)
+	if err != nil {
+		return exprValue{}, err
+	}
+	right, err := n.right.eval(lookup)
+	if err != nil {
+		return exprValue{}, err
+	}
+	order := strings.Compare(left.text, right.text)
+	if l, err := strconv.ParseFloat(left.text, 64); err == nil {
+		if r, err := strconv.ParseFloat(right.text, 64); err == nil {
+			switch {
+			case l < r:
+				order = -1
+			case l > r:
+				order = 1
+			default:
+				order = 0
+			}
+		}
+	}
+	switch n.operator {
+	case "==":
+		return boolValue(order == 0), nil
+	case "!=":
+		return boolValue(order != 0), nil
+	case "<":
+		return boolValue(order < 0), nil
+	case "<=":
+		return boolValue(order <= 0), nil
+	case ">":
+		return boolValue(order > 0), nil
+	}
+	return boolValue(order >= 0), nil
+}
+
+func (n *compareNode) names(add func(string)) {
+	n.left.names(add)
+	n.right.names(add)
+}
+
+type arithmeticNode struct {
+	operator    string
+	left, right exprNode
+}
+
+// integers give integer results, as in Go, so 7 / 2 is 3. if either side has a decimal point the result is a
+// float. + joins the text when either side is not a number
+func (n *arithmeticNode) eval(lookup exprLookup) (exprValue, error) {
+	left, err := n.left.eval(lookup)
+	if err != nil {
+		return exprValue{}, err
+	}
+	right, err := n.right.eval(lookup)
+	if err != nil {
+		return exprValue{}, err
+	}
+	l, lErr := strconv.ParseInt(left.text, 10, 64)
+	r, rErr := strconv.ParseInt(right.text, 10, 64)
+	if lErr == nil && rErr == nil {
+		return integerArithmetic(n.operator, l, r)
+	}
+	lf, lErr := strconv.ParseFloat(left.text, 64)
+	rf, rErr := strconv.ParseFloat(right.text, 64)
+	if lErr == nil && rErr == nil {
+		return floatArithmetic(n.operator, lf, rf)
+	}
+	if n.operator == "+" {
+		return exprValue{text: left.text + right.text, defined: left.defined || right.defined}, nil
+	}
+	notNumber := left
+	if lErr == nil {
+		notNumber = right
+	}
+	return exprValue{}, fmt.Errorf("'%s' needs numbers but %s is not a number", n.operator, notNumber)
+}
+
+func integerArithmetic(operator string, l int64, r int64) (exprValue, error) {
+	var result int64
+	switch operator {
+	case "+":
+		result = l + r
+	case "-":
+		result = l - r
+	case "*":
+		result = l * r
+	default:
+		if r == 0 {
+			return exprValue{}, fmt.Errorf("division by zero")
+		}
+		if operator == "/" {
+			result = l / r
+		} else {
+			result = l % r
+		}
+	}
+	return exprValue{text: strconv.FormatInt(result, 10), defined: true}, nil
+}
+
+func floatArithmetic(operator string, l float64, r float64) (exprValue, error) {
+	var result float64
+	switch operator {
+	case "+":
+		result = l + r
+	case "-":
+		result = l - r
+	case "*":
+		result = l * r
+	default:
+		if r == 0 {
+			return exprValue{}, fmt.Errorf("division by zero")
+		}
+		if operator == "/" {
+			result = l / r
+		} else {
+			result = math.Mod(l, r)
+		}
+	}
+	return exprValue{text: strconv.FormatFloat(result, 'f', -1, 64), defined: true}, nil
+}
+
+func (n *arithmeticNode) names(add func(string)) {
+	n.left.names(add)
+	n.right.names(add)
+}
+
+// a filter applied to a value with |, such as ${name | upper} or ${name | default "x"}
+type exprFilter struct {
+	arguments int
+	apply     func(v exprValue, arguments []exprValue) exprValue
+}
+
+var exprFilters = map[string]exprFilter{
+	"lower": {0, func(v exprValue, _ []exprValue) exprValue {
+		v.text = strings.ToLower(v.text)
+		return v
+	}},
+	"upper": {0, func(v exprValue, _ []exprValue) exprValue {
+		v.text = strings.ToUpper(v.text)
+		return v
+	}},
+	"trim": {0, func(v exprValue, _ []exprValue) exprValue {
+		v.text = strings.TrimSpace(v.text)
+		return v
+	}},
+	"quote": {0, func(v exprValue, _ []exprValue) exprValue {
+		return exprValue{text: strconv.Quote(v.text), defined: true}
+	}},
+	// the argument replaces a value that is missing or empty
+	"default": {1, func(v exprValue, arguments []exprValue) exprValue {
+		if !v.defined || v.text == "" {
+			return arguments[0]
+		}
+		return v
+	}},
+}
+
+type filterNode struct {
+	name      string
+	operand   exprNode
+	arguments []exprNode
+}
+
+func (n *filterNode) eval(lookup exprLookup) (exprValue, error) {
+	v, err := n.operand.eval(lookup)
+	if err != nil {
+		return exprValue{}, err
+	}
+	arguments := make([]exprValue, len(n.arguments))
+	for i, argument := range n.arguments {
+		if arguments[i], err = argument.eval(lookup); err != nil {
+			return exprValue{}, err
+		}
+	}
+	return exprFilters[n.name].apply(v, arguments), nil
+}
+
+func (n *filterNode) names(add func(string)) {
+	n.operand.names(add)
+	for _, argument := range n.arguments {
+		argument.names(add)
+	}
+}
@@ -0,0 +1,220 @@
+package simpleProperties
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_scanExpressions(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  []string
+	}{
+		{"none", "plain text", []string{}},
+		{"plain references", "${host}:${port:80}", []string{"${host}", "${port:80}"}},
+		{"operators", "${a ? b : c} and ${x ?: y}", []string{"${a ? b : c}", "${x ?: y}"}},
+		{"brace in string", "${a ? '}' : '{'}!", []string{"${a ? '}' : '{'}"}},
+		{"quote in default", "${name:O'Brien}", []string{"${name:O'Brien}"}},
+		{"empty and unterminated", "${} ${a", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []string{}
+			for _, e := range scanExpressions(tt.value) {
+				got = append(got, e.full)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("scanExpressions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseExpressionErrors(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{"a ? b", "expected ':' but found end of expression at offset 5"},
+		{"a b", "unexpected 'b' at offset 2"},
+		{"(a", "expected ')' but found end of expression at offset 2"},
+		{"a == 'b", "unterminated string at offset 5"},
+		{"if:a:b", "if needs a condition, a value and an alternative separated by ':'"},
+		{"a && ", "expected a value but found end of expression at offset 5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.content, func(t *testing.T) {
+			parts := parseExpression("${"+tt.content+"}", tt.content)
+			if parts.err == nil {
+				t.Fatalf("parseExpression() no error, want %v", tt.want)
+			}
+			if want := "expression ${" + tt.content + "}: " + tt.want; parts.err.Error() != want {
+				t.Errorf("parseExpression() error = %v, want %v", parts.err, want)
+			}
+		})
+	}
+}
+
+func TestConditionalExpressions(t *testing.T) {
+	values := map[string]string{
+		"feature.enabled": "true",
+		"feature.off":     "off",
+		"profile":         "prod",
+		"empty":           "",
+		"b":               "B",
+		"count":           "10",
+		"scheme":          "${feature.enabled ? https : http}",
+		"plain":           "${feature.off ? https : http}",
+		"missing":         "${no.such.key ? https : http}",
+		"mode.prod":       "${if:profile==prod:strict:relaxed}",
+		"mode.dev":        "${if:profile==dev:strict:relaxed}",
+		"coalesce":        "${a ?: b ?: literal}",
+		"coalesce.empty":  "[${empty ?: b}]",
+		"coalesce.none":   "${a ?: c ?: literal}",
+		"logic":           "${feature.enabled && !feature.off}",
+		"grouped":         "${(feature.off || profile == prod) ? yes : no}",
+		"numeric":         "${count > 9 ? big : small}",
+		"text":            "${'apple' < 'banana'}",
+		"quoted":          "${profile == prod ? 'a b' : \"c}\"}",
+		"nested":          "${profile != prod ? x : b == B ? both : one}",
+		"uses.result":     "${scheme}://${mode.prod}",
+		"url":             "${scheme}://host:${prop:port:8080}",
+	}
+	want := map[string]string{
+		"scheme":         "https",
+		"plain":          "http",
+		"missing":        "http",
+		"mode.prod":      "strict",
+		"mode.dev":       "relaxed",
+		"coalesce":       "B",
+		"coalesce.empty": "[]",
+		"coalesce.none":  "literal",
+		"logic":          "true",
+		"grouped":        "yes",
+		"numeric":        "big",
+		"text":           "true",
+		"quoted":         "a b",
+		"nested":         "both",
+		"uses.result":    "https://strict",
+		"url":            "https://host:8080",
+	}
+	p := EmptyProperties()
+	p.SetEvaluators(DefaultEvaluator())
+	p.Merge(values)
+	if err := p.Evaluate(); err != nil {
+		t.Fatalf("Evaluate() error = %v", err)
+	}
+	for k, v := range want {
+		if got := p.GetProperty(k); got != v {
+			t.Errorf("GetProperty(%s) = %v, want %v", k, got, v)
+		}
+	}
+	if got := p.DependsOn("scheme"); !reflect.DeepEqual(got, []string{"feature.enabled", "http", "https"}) {
+		t.Errorf("DependsOn(scheme) = %v", got)
+	}
+}
+
+func TestConditionalExpressionError(t *testing.T) {
+	p := EmptyProperties()
+	p.SetEvaluators(DefaultEvaluator())
+	p.Set("a", "x ${b ? c} y")
+	err := p.Evaluate()
+	if err == nil || !strings.Contains(err.Error(), "a: expression ${b ? c}: expected ':'") {
+		t.Fatalf("Evaluate() error = %v", err)
+	}
+	if got := p.GetEvalProperty("a"); got != "x ${b ? c} y" {
+		t.Errorf("GetEvalProperty(a) = %v", got)
+	}
+}
+
+func TestArithmeticExpressions(t *testing.T) {
+	values := map[string]string{
+		"cpu.count":   "4",
+		"half":        "0.5",
+		"name":        "Billing",
+		"padded":      "${'  ' + name + ' ' | trim}",
+		"empty":       "",
+		"max-pool":    "8",
+		"product":     "${cpu.count * 4}",
+		"precedence":  "${1 + cpu.count * 2 - (3 - 1)}",
+		"division":    "${7 / 2} ${7 % 2} ${7.0 / 2}",
+		"float":       "${half * 3}",
+		"negative":    "${-cpu.count + 1} ${- (2 * 3)} ${cpu.count -1} ${-1 * -2}",
+		"kebab":       "${max-pool - 1}",
+		"concat":      "${'pool-' + cpu.count + '-' + half}",
+		"lower":       "${name | trim | lower}",
+		"upper":       "${name|upper|quote}",
+		"default":     "${empty | default \"x\"} ${missing | default 'y'} ${name | default 'z' | trim}",
+		"ternary":     "${cpu.count > 2 ? name | trim : 'small'}",
+		"grouped":     "${(cpu.count + 1) * 2 | quote}",
+		"compare":     "${cpu.count * 2 == max-pool}",
+		"coalesce":    "${missing ?: cpu.count * 10}",
+		"if":          "${if:cpu.count>2:big:small}",
+		"multiply":    "${2*3}",
+		"concat.word": "${missing + '!'}",
+	}
+	want := map[string]string{
+		"product":     "16",
+		"precedence":  "7",
+		"division":    "3 1 3.5",
+		"float":       "1.5",
+		"negative":    "-3 -6 3 2",
+		"kebab":       "7",
+		"concat":      "pool-4-0.5",
+		"lower":       "billing",
+		"padded":      "Billing",
+		"upper":       "\"BILLING\"",
+		"default":     "x y Billing",
+		"ternary":     "Billing",
+		"grouped":     "\"10\"",
+		"compare":     "true",
+		"coalesce":    "40",
+		"if":          "big",
+		"multiply":    "6",
+		"concat.word": "missing!",
+	}
+	p := EmptyProperties()
+	p.SetEvaluators(DefaultEvaluator())
+	p.Merge(values)
+	if err := p.Evaluate(); err != nil {
+		t.Fatalf("Evaluate() error = %v", err)
+	}
+	for k, v := range want {
+		if got := p.GetProperty(k); got != v {
+			t.Errorf("GetProperty(%s) = %v, want %v", k, got, v)
+		}
+	}
+}
+
+func TestArithmeticExpressionErrors(t *testing.T) {
+	p := EmptyProperties()
+	p.SetEvaluators(DefaultEvaluator())
+	GlobalPropertyLoader("testdata/resources/expressions")(p)
+	p.Set("zero", "${cpu.count / 0}")
+	p.Set("filter", "${name | reverse}")
+	err := p.Evaluate()
+	var loadError *LoadError
+	if !errors.As(err, &loadError) {
+		t.Fatalf("Evaluate() error = %v, want a *LoadError", err)
+	}
+	want := []string{
+		"testdata/resources/expressions.properties: broken: expression ${service * 2}: '*' needs numbers but service (\"billing\") is not a number",
+		"filter: expression ${name | reverse}: unknown filter 'reverse' at offset 7",
+		"zero: expression ${cpu.count / 0}: division by zero",
+	}
+	got := []string{}
+	for _, e := range loadError.Errors {
+		got = append(got, e.Error())
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Evaluate() errors = %q, want %q", got, want)
+	}
+	for k, v := range map[string]string{"pool.max": "16", "timeout.ms": "1500", "name": "billing-eu-west"} {
+		if got := p.GetProperty(k); got != v {
+			t.Errorf("GetProperty(%s) = %v, want %v", k, got, v)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package simpleProperties
+
+import (
+	"flag"
+	"sort"
+	"strings"
+)
+
+// Flag a command line flag, as seen by a properties structure it is bound to
+type Flag struct {
+	Name      string
+	Shorthand string // a one letter name, such as v for -v, if any
+	Usage     string
+	Value     string
+	Default   string
+	Set       bool // whether it was given on the command line
+	IsBool    bool // whether it is given without a value, as -v rather than -v true
+}
+
+// FlagSource a set of command line flags that can be bound to properties, such as a flag.FlagSet. See BindFlags,
+// and the pflagProperties package for a pflag.FlagSet
+type FlagSource interface {
+	// Flags every flag in the set, with its current value
+	Flags() []Flag
+}
+
+// BindFlagSource bind a set of command line flags to properties of the same name. A flag given on the command line
+// overrides the property, and the default of a flag is used when nothing else, not even SetDefault, gives the property a value.
+// Arguments for the flags are not read as -key=value CLI parameters, so the properties and the application's own
+// flags can share the command line. Parse the flags before calling Load or Evaluate
+func (p *Properties) BindFlagSource(s FlagSource) {
+	p.flagSources = append(p.flagSources, s)
+}
+
+// BindFlags bind a standard flag.FlagSet to properties, as described for BindFlagSource
+//
+//	port := flag.Int("server.port", 8080, "the port to listen on")
+//	properties.BindFlags(flag.CommandLine)
+//	flag.Parse()
+//	err := properties.Load()
+func (p *Properties) BindFlags(fs *flag.FlagSet) {
+	p.BindFlagSource(standardFlags{fs})
+}
+
+// RegisterFlags define a string flag in a flag.FlagSet for each of the properties, then bind the set as BindFlags
+// does. Each flag defaults to the property's current value, and its usage is the description given by the schema.
+// A property that looks like it holds a secret, as judged by RedactSensitive, has no default, so that -help doesn't
+// show it. With no keys, a flag is defined for every property loaded and every property in the schema. A key that
+// is already a flag is left as it is
+func (p *Properties) RegisterFlags(fs *flag.FlagSet, keys ...string) {
+	for _, f := range p.PropertyFlags(keys...) {
+		if fs.Lookup(f.Name) == nil {
+			fs.String(f.Name, f.Default, f.Usage)
+		}
+	}
+	p.BindFlags(fs)
+}
+
+// PropertyFlags describe flags for setting properties, as RegisterFlags defines them, in key order. The value and
+// default of a property that looks like it holds a secret are left empty
+func (p *Properties) PropertyFlags(keys ...string) []Flag {
+	if len(keys) == 0 {
+		seen := make(map[string]bool)
+		for _, list := range [][]string{p.GetKeys(), p.GetEvalKeys(), p.schema.sortedKeys()} {
+			for _, key := range list {
+				if !seen[key] && p.schema[key].kindOrEmpty() != kindObject {
+					seen[key] = true
+					keys = append(keys, key)
+				}
+			}
+		}
+		sort.Strings(keys)
+	}
+	flags := make([]Flag, 0, len(keys))
+	for _, key := range keys {
+		usage := "sets the " + key + " property"
+		if rule, found := p.schema[key]; found && rule.description != "" {
+			usage = rule.description
+		}
+		value := p.GetProperty(key)
+		if sensitiveKey(key) {
+			value = ""
+		}
+		flags = append(flags, Flag{Name: key, Usage: usage, Default: value, Value: value})
+	}
+	return flags
+}
+
+// the kind of a rule, allowing for there being no rule
+func (r *Rule) kindOrEmpty() string {
+	if r == nil {
+		return ""
+	}
+	return r.kind
+}
+
+// set the properties given by bound flags on the command line
+func (p *Properties) applyFlags() {
+	for _, s := range p.flagSources {
+		for _, f := range s.Flags() {
+			if f.Set {
+				setKVFrom(p, f.Name, f.Value, commandLineSource)
+			}
+		}
+	}
+}
+
+// the defaults of the bound flags not given on the command line, for properties with no other default
+func (p *Properties) flagDefaults() map[string]string {
+	defaults := make(map[string]string)
+	for _, s := range p.flagSources {
+		for _, f := range s.Flags() {
+			key := p.storedKey(strings.Trim(f.Name, " \t"))
+			if _, found := p.defaultKeyValueMap[key]; !found && !f.Set && f.Default != "" && key != "" {
+				defaults[key] = strings.Trim(f.Default, " \t")
+			}
+		}
+	}
+	return defaults
+}
+
+// the bound flags, by name and shorthand
+func (p *Properties) boundFlags() map[string]Flag {
+	flags := make(map[string]Flag)
+	for _, s := range p.flagSources {
+		for _, f := range s.Flags() {
+			flags[f.Name] = f
+			if f.Shorthand != "" {
+				flags[f.Shorthand] = f
+			}
+		}
+	}
+	return flags
+}
+
+// a flag.FlagSet as a FlagSource
+type standardFlags struct {
+	fs *flag.FlagSet
+}
+
+func (s standardFlags) Flags() []Flag {
+	set := make(map[string]bool)
+	s.fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	flags := []Flag{}
+	s.fs.VisitAll(func(f *flag.Flag) {
+		b, isBool := f.Value.(interface{ IsBoolFlag() bool })
+		flags = append(flags, Flag{
+			Name:    f.Name,
+			Usage:   f.Usage,
+			Value:   f.Value.String(),
+			Default: f.DefValue,
+			Set:     set[f.Name],
+			IsBool:  isBool && b.IsBoolFlag(),
+		})
+	})
+	return flags
+}
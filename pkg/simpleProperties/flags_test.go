@@ -0,0 +1,185 @@
+package simpleProperties
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_cliParameters(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want [][2]string
+	}{
+		{"equals", []string{"app", "-a=1", "--b=2", "-c=", "--unknown=3"}, [][2]string{{"a", "1"}, {"b", "2"}, {"c", ""}, {"unknown", "3"}}},
+		{"space", []string{"app", "-a", "1", "--b", "two words"}, [][2]string{{"a", "1"}, {"b", "two words"}}},
+		{"space for an unknown key", []string{"app", "-debug", "input.txt", "-a=1"}, [][2]string{{"a", "1"}}},
+		{"space takes the next argument", []string{"app", "-a", "input.txt"}, [][2]string{{"a", "input.txt"}}},
+		{"profile key", []string{"app", "-profile", "prod"}, [][2]string{{"profile", "prod"}}},
+		{"negative value", []string{"app", "-offset", "-5", "--b", "-1.5"}, [][2]string{{"offset", "-5"}, {"b", "-1.5"}}},
+		{"negative value for an unknown key", []string{"app", "-shift", "-5"}, [][2]string{}},
+		{"no value", []string{"app", "-a", "-b=2", "--c"}, [][2]string{{"b", "2"}}},
+		{"positional", []string{"app", "file.txt", "-", "-a=1", "---b=2"}, [][2]string{{"a", "1"}}},
+		{"terminator", []string{"app", "-a=1", "--", "-b=2"}, [][2]string{{"a", "1"}}},
+		{"run param only", []string{"app"}, [][2]string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := EmptyProperties()
+			p.Merge(map[string]string{"a": "", "b": "", "c": ""})
+			p.SetDefault("offset", "0")
+			if got := p.cliParameters(tt.args); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("cliParameters() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBindFlags(t *testing.T) {
+	args := os.Args
+	defer func() { os.Args = args }()
+	os.Args = []string{"app", "-v", "-name", "report", "--server.port", "9090", "input.txt", "-colour=red", "--", "-size=1"}
+
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	fs.Bool("v", false, "verbose")
+	fs.String("name", "", "the report name")
+	fs.Int("server.port", 8080, "the port to listen on")
+	fs.String("server.host", "localhost", "the host")
+	fs.String("timeout", "30s", "the timeout")
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(fs.Args(), []string{"input.txt", "-colour=red", "--", "-size=1"}) {
+		t.Fatalf("unexpected arguments %v", fs.Args())
+	}
+
+	p := EmptyProperties()
+	p.operations = []func(*Properties){LoadCLIParameters()}
+	p.SetEvaluators(DefaultEvaluator())
+	p.Merge(map[string]string{"server.port": "80", "server.host": "example.com", "url": "${server.host}:${server.port}"})
+	p.SetDefault("timeout", "10s")
+	p.BindFlags(fs)
+	if err := p.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	want := map[string]string{
+		"v":           "true",        // set on the command line
+		"name":        "report",      // set on the command line
+		"server.port": "9090",        // the flag overrides the property
+		"server.host": "example.com", // the property overrides the flag default
+		"timeout":     "10s",         // SetDefault overrides the flag default
+		"colour":      "red",         // a CLI parameter among the arguments left by the flags
+		"url":         "example.com:9090",
+	}
+	for key, value := range want {
+		if got := p.GetProperty(key); got != value {
+			t.Errorf("GetProperty(%s) = %q, want %q", key, got, value)
+		}
+	}
+	for _, key := range []string{"input.txt", "size"} {
+		if got := p.GetProperty(key); got != "" {
+			t.Errorf("GetProperty(%s) = %q, want nothing", key, got)
+		}
+	}
+	if got := p.sources["server.port"]; got != commandLineSource {
+		t.Errorf("source of server.port = %q", got)
+	}
+
+	// a flag default is used when nothing else gives a value
+	p.Unset("server.host")
+	p.Unset("timeout")
+	if err := p.Evaluate(); err != nil {
+		t.Fatalf("Evaluate() error = %v", err)
+	}
+	if got := p.GetProperty("server.host"); got != "localhost" {
+		t.Errorf("GetProperty(server.host) = %q, want the flag default", got)
+	}
+	if got := p.GetProperty("timeout"); got != "10s" {
+		t.Errorf("GetProperty(timeout) = %q, want the SetDefault value", got)
+	}
+}
+
+func TestBindFlagsProfile(t *testing.T) {
+	args := os.Args
+	defer func() { os.Args = args }()
+	os.Args = []string{"app", "--profile", "prod"}
+
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	fs.String(profileKey, "", "the profiles to use")
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		t.Fatal(err)
+	}
+	p := EmptyProperties()
+	p.BindFlags(fs)
+	if got := selectProfiles(p); !reflect.DeepEqual(got, []string{"prod"}) {
+		t.Errorf("selectProfiles() = %v", got)
+	}
+}
+
+func TestRegisterFlags(t *testing.T) {
+	schema, err := LoadSchema(strings.NewReader(`{"properties": {"server": {"properties": {
+		"port": {"type": "integer", "description": "the port to listen on"}}}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := EmptyProperties()
+	p.SetEvaluators(DefaultEvaluator())
+	p.SetSchema(schema)
+	p.Merge(map[string]string{"server.port": "8080", "server.host": "localhost", "db.password": "s3cret"})
+	if err := p.Evaluate(); err != nil {
+		t.Fatalf("Evaluate() error = %v", err)
+	}
+
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	fs.Int("server.host", 0, "already defined")
+	p.RegisterFlags(fs)
+	usage := &bytes.Buffer{}
+	fs.SetOutput(usage)
+	fs.PrintDefaults()
+	want := "  -db.password string\n    \tsets the db.password property\n" +
+		"  -server.host int\n    \talready defined\n" +
+		"  -server.port string\n    \tthe port to listen on (default \"8080\")\n"
+	if usage.String() != want {
+		t.Errorf("usage\n%s\nwant\n%s", usage, want)
+	}
+
+	if err := fs.Parse([]string{"-server.port=9090"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Evaluate(); err != nil {
+		t.Fatalf("Evaluate() error = %v", err)
+	}
+	if got := p.GetProperty("server.port"); got != "9090" {
+		t.Errorf("GetProperty(server.port) = %q", got)
+	}
+	if got := p.GetProperty("db.password"); got != "s3cret" {
+		t.Errorf("GetProperty(db.password) = %q, want the loaded value", got)
+	}
+
+	flags := p.PropertyFlags("server.host", "nothing")
+	if len(flags) != 2 || flags[0].Default != "localhost" || flags[1].Usage != "sets the nothing property" {
+		t.Errorf("PropertyFlags() = %+v", flags)
+	}
+}
+
+func TestLoadCLIParametersLogging(t *testing.T) {
+	args := os.Args
+	defer func() { os.Args = args }()
+	os.Args = []string{"app", "-db.password=s3cret", "-name=report"}
+
+	logger := &recordingLogger{}
+	p := EmptyProperties()
+	p.SetLogger(logger)
+	LoadCLIParameters()(p)
+	want := []string{"CLI key db.password = ******", "CLI key name = report"}
+	if !reflect.DeepEqual(logger.messages, want) {
+		t.Errorf("logged %v, want %v", logger.messages, want)
+	}
+	if got := p.GetProperty("db.password"); got != "s3cret" {
+		t.Errorf("GetProperty(db.password) = %q, want the value given", got)
+	}
+}
@@ -0,0 +1,107 @@
+package simpleProperties
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"strings"
+	"sync"
+)
+
+// FormatLoader decode the content of a property file into a map of values. nested maps are flattened into
+// dotted keys in the same way as for .json and .yaml files
+type FormatLoader func(r io.Reader) (map[string]interface{}, error)
+
+var formatLock sync.RWMutex
+
+// loaders for each known file extension
+var formatLoaders = map[string]func(*Properties, fs.File){
+	".yaml":       loadYAML,
+	".json":       loadJSON,
+	".properties": loadPropertiesFromFile,
+}
+
+// the order files are probed in when a properties structure has no format order of its own. least to highest
+// precedence
+var defaultFormats = []string{".yaml", ".json", ".properties"}
+
+// RegisterFormat add a loader for files with the given extension, e.g. RegisterFormat(".toml", tomlLoader).
+// a new extension is added to the end of the default format order, so its files take precedence over the
+// built-in formats. registering a known extension replaces its loader.
+//
+// note: the bootstrap files are loaded when the package is initialised, so they only use the built-in formats
+func RegisterFormat(extension string, loader FormatLoader) {
+	extension = normaliseExtension(extension)
+	formatLock.Lock()
+	defer formatLock.Unlock()
+	formatLoaders[extension] = func(p *Properties, file fs.File) {
+		defer file.Close()
+		result, err := loader(file)
+		if err != nil {
+			log.Fatalf("Invalid properties %s file. Error %s", extension, err)
+		}
+		extractKVMap(p, result, "")
+	}
+	for _, known := range defaultFormats {
+		if known == extension {
+			return
+		}
+	}
+	defaultFormats = append(defaultFormats, extension)
+}
+
+// SetFormats set the file extensions probed when loading, and their precedence, from least to highest.
+// e.g. SetFormats(".properties", ".yaml") lets a .yaml file override a .properties file of the same name, and
+// SetFormats(".yaml") only ever loads .yaml files. calling with no extensions restores the default order
+func (p *Properties) SetFormats(extensions ...string) error {
+	formats := make([]string, 0, len(extensions))
+	formatLock.RLock()
+	defer formatLock.RUnlock()
+	for _, extension := range extensions {
+		extension = normaliseExtension(extension)
+		if _, found := formatLoaders[extension]; !found {
+			return fmt.Errorf("no loader registered for file extension %s", extension)
+		}
+		formats = append(formats, extension)
+	}
+	if len(formats) == 0 {
+		p.formats = nil
+	} else {
+		p.formats = formats
+	}
+	return nil
+}
+
+// GetFormats get the file extensions probed when loading, from least to highest precedence
+func (p *Properties) GetFormats() []string {
+	return p.formatOrder()
+}
+
+// the format order for a properties structure, falling back to the default order
+func (p *Properties) formatOrder() []string {
+	formatLock.RLock()
+	defer formatLock.RUnlock()
+	var formats []string
+	if p.formats != nil {
+		formats = p.formats
+	} else {
+		formats = defaultFormats
+	}
+	return append([]string{}, formats...)
+}
+
+// the loader for a file extension
+func formatLoader(extension string) func(*Properties, fs.File) {
+	formatLock.RLock()
+	defer formatLock.RUnlock()
+	return formatLoaders[extension]
+}
+
+// make sure an extension starts with a '.'
+func normaliseExtension(extension string) string {
+	if strings.HasPrefix(extension, ".") {
+		return extension
+	}
+	return "." + extension
+}
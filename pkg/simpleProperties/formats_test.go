@@ -0,0 +1,84 @@
+package simpleProperties
+
+import (
+	"bufio"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// a trivial key:value per line format used to test format registration
+func colonLoader(r io.Reader) (map[string]interface{}, error) {
+	result := make(map[string]interface{})
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		key, value, _ := strings.Cut(scanner.Text(), ":")
+		result[key] = value
+	}
+	return result, scanner.Err()
+}
+
+func TestSetFormats(t *testing.T) {
+	tests := []struct {
+		name    string
+		formats []string
+		want    map[string]string
+	}{
+		{"default order",
+			nil,
+			map[string]string{"format": "properties", "yaml": "only", "json": "only", "properties": "only"},
+		},
+		{"reversed order",
+			[]string{".properties", ".json", ".yaml"},
+			map[string]string{"format": "yaml", "yaml": "only", "json": "only", "properties": "only"},
+		},
+		{"single format",
+			[]string{"yaml"},
+			map[string]string{"format": "yaml", "yaml": "only"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := EmptyProperties()
+			if err := p.SetFormats(tt.formats...); err != nil {
+				t.Fatalf("SetFormats() error = %v", err)
+			}
+			GlobalPropertyLoader("testdata/resources/formats")(p)
+			if !reflect.DeepEqual(p.keyValueMap, tt.want) {
+				t.Errorf("GlobalPropertyLoader() = %v, want %v", p.keyValueMap, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetFormatsUnknown(t *testing.T) {
+	p := EmptyProperties()
+	if err := p.SetFormats(".yaml", ".unknown"); err == nil {
+		t.Errorf("SetFormats() expected an error for an unregistered extension")
+	}
+	if !reflect.DeepEqual(p.GetFormats(), []string{".yaml", ".json", ".properties"}) {
+		t.Errorf("GetFormats() = %v, want the default order", p.GetFormats())
+	}
+}
+
+func TestRegisterFormat(t *testing.T) {
+	saved := append([]string{}, defaultFormats...)
+	t.Cleanup(func() {
+		defaultFormats = saved
+		delete(formatLoaders, ".test")
+	})
+	RegisterFormat("test", colonLoader)
+	p := EmptyProperties()
+	formats := p.GetFormats()
+	if formats[len(formats)-1] != ".test" {
+		t.Errorf("GetFormats() = %v, want .test last", formats)
+	}
+	GlobalPropertyLoader("testdata/resources/formats")(p)
+	if got := p.GetProperty("format"); got != "test" {
+		t.Errorf("GetProperty(format) = %v, want %v", got, "test")
+	}
+	if got := p.GetProperty("test"); got != "only" {
+		t.Errorf("GetProperty(test) = %v, want %v", got, "only")
+	}
+}
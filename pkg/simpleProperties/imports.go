@@ -0,0 +1,151 @@
+package simpleProperties
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// keys naming further files to load, see loadImports
+var importKeys = []string{"import", "config.import"}
+
+const optionalPrefix = "optional:"
+const configTreePrefix = "configtree:"
+
+// load the files named by an import key. entries are relative to the directory of the importing file, and may be
+//
+//	name.ext            a single file, loaded with the loader for its extension
+//	name                a file name without an extension, looked for in each format as with the application files
+//	conf.d/*.yaml       a glob, with the matching files loaded in name order
+//	configtree:secrets  a directory tree with one file per property. the path of a file, with / replaced by ., is
+//	                    the key and its content the value, as with a Kubernetes config map or secret volume
+//
+// imports are loaded in the order listed, straight after the importing file, so they override it and are overridden
+// by any later file. an entry can start with optional: so that it is not an error for it to be missing. a file
+// importing itself, directly or not, is an error
+func loadImports(p *Properties, from string, imports []string, chain []string) {
+	for _, entry := range imports {
+		optional := strings.HasPrefix(entry, optionalPrefix)
+		location := strings.TrimPrefix(entry, optionalPrefix)
+		configTree := strings.HasPrefix(location, configTreePrefix)
+		location = strings.TrimPrefix(location, configTreePrefix)
+		if !filepath.IsAbs(location) {
+			location = filepath.Join(filepath.Dir(from), location)
+		}
+		var err error
+		switch {
+		case configTree:
+			err = loadConfigTree(p, location)
+		case strings.ContainsAny(filepath.Base(location), "*?["):
+			err = loadGlob(p, location, chain)
+		case filepath.Ext(location) != "":
+			err = loadImport(p, location, chain)
+		default:
+			found := false
+			for _, extension := range p.formatOrder() {
+				if _, statErr := os.Stat(location + extension); statErr == nil {
+					found = true
+					err = loadImport(p, location+extension, chain)
+					if err != nil {
+						break
+					}
+				}
+			}
+			if !found {
+				err = fs.ErrNotExist
+			}
+		}
+		if err != nil && !(optional && errors.Is(err, fs.ErrNotExist)) {
+			p.errs = append(p.errs, fmt.Errorf("%s: import %s: %w", from, entry, err))
+		}
+	}
+}
+
+// load a single imported file
+func loadImport(p *Properties, path string, chain []string) error {
+	for _, importing := range chain {
+		if importing == path {
+			return fmt.Errorf("import cycle through %s", path)
+		}
+	}
+	loader := formatLoader(filepath.Ext(path))
+	if loader == nil {
+		return fmt.Errorf("no loader registered for file extension %s", filepath.Ext(path))
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	loadFile(p, loader, file, path, chain)
+	return nil
+}
+
+// load the files matching a glob. files without a registered loader are skipped
+func loadGlob(p *Properties, pattern string, chain []string) error {
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return err
+	}
+	found := false
+	for _, match := range matches {
+		info, err := os.Stat(match)
+		if err != nil || info.IsDir() || formatLoader(filepath.Ext(match)) == nil {
+			continue
+		}
+		found = true
+		if err := loadImport(p, match, chain); err != nil {
+			return err
+		}
+	}
+	if !found {
+		return fs.ErrNotExist
+	}
+	return nil
+}
+
+// load a directory tree holding one file per property. hidden files and directories, such as the ..data
+// links Kubernetes creates, are skipped. a single trailing line end is removed from each value
+func loadConfigTree(p *Properties, dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+	scratch := &Properties{
+		keyValueMap:     make(map[string]string),
+		evalKeyValueMap: make(map[string]string),
+		evalExprMap:     make(map[string]*list.List),
+	}
+	err = fs.WalkDir(os.DirFS(dir), ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != "." && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		content, err := os.ReadFile(filepath.Join(dir, path))
+		if err != nil {
+			return err
+		}
+		value := strings.TrimSuffix(strings.TrimSuffix(string(content), "\n"), "\r")
+		setKVFrom(scratch, strings.ReplaceAll(path, "/", "."), value, filepath.Join(dir, path))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	mergeProperties(p, scratch, dir)
+	return nil
+}
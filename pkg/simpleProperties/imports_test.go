@@ -0,0 +1,85 @@
+package simpleProperties
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestImports(t *testing.T) {
+	p := EmptyProperties()
+	GlobalPropertyLoader("testdata/resources/imports/application")(p)
+	if len(p.errs) != 0 {
+		t.Fatalf("GlobalPropertyLoader() errors = %v", p.errs)
+	}
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"level", "nested"},
+		{"extra", "yes"},
+		{"a", "1"},
+		{"db.password", "secret"},
+		{"user", "admin"},
+		{"common", "yes"},
+		{"nested", "yes"},
+		{"import", ""},
+		{"value", ""},
+		{".hidden.value", ""},
+	}
+	for _, tt := range tests {
+		if got := p.GetProperty(tt.key); got != tt.want {
+			t.Errorf("GetProperty(%v) = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestImportErrors(t *testing.T) {
+	p := EmptyProperties()
+	p.operations = []func(*Properties){GlobalPropertyLoader("testdata/resources/imports/broken")}
+	err := p.Load()
+	var loadError *LoadError
+	if !errors.As(err, &loadError) {
+		t.Fatalf("Load() error = %v, want a *LoadError", err)
+	}
+	if len(loadError.Errors) != 3 {
+		t.Errorf("Load() errors = %v, want missing file, cycle and empty glob", loadError.Errors)
+	}
+	if p.GetProperty("cycle") != "yes" {
+		t.Errorf("GetProperty(cycle) = %v, want %v", p.GetProperty("cycle"), "yes")
+	}
+	if err := p.Load(); err == nil {
+		t.Errorf("Load() again should report the same problems")
+	}
+}
+
+func TestImportsInHeldDocument(t *testing.T) {
+	p := EmptyProperties()
+	p.SetProfiles("dev")
+	GlobalPropertyLoader("testdata/resources/imports/held")(p)
+	ProfilePropertyLoader("testdata/resources/imports/held")(p)
+	if len(p.errs) != 0 {
+		t.Fatalf("loader errors = %v", p.errs)
+	}
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"mode", "development"},
+		{"debug", "true"},
+		{"import", ""},
+	}
+	for _, tt := range tests {
+		if got := p.GetProperty(tt.key); got != tt.want {
+			t.Errorf("GetProperty(%v) = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+	for key, source := range map[string]string{
+		"mode":  "testdata/resources/imports/held.yaml",
+		"debug": "testdata/resources/imports/held_extra.properties",
+	} {
+		if got := p.Explain(key); !strings.Contains(got, "source: "+source) {
+			t.Errorf("Explain(%v) = %q, want the source %v", key, got, source)
+		}
+	}
+}
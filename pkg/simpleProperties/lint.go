@@ -0,0 +1,686 @@
+package simpleProperties
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Severity how serious a lint finding is
+type Severity string
+
+const (
+	SeverityError   Severity = "error"   // the properties won't load as intended
+	SeverityWarning Severity = "warning" // probably a mistake
+	SeverityNote    Severity = "note"    // worth a look
+)
+
+// the rules checked by Lint
+const (
+	ruleInvalidFile         = "invalid-file"         // a file that can't be read
+	ruleLoadError           = "load-error"           // a problem loading the files, such as a missing import
+	ruleDuplicateKey        = "duplicate-key"        // a key set twice in one file
+	ruleShadowedKey         = "shadowed-key"         // a key overridden by a file of the same name in another format
+	ruleUndefinedReference  = "undefined-reference"  // ${name} where name is set in no file
+	ruleUnusedDefault       = "unused-default"       // ${prop:name:default} where name is always set
+	ruleReferenceCycle      = "reference-cycle"      // properties referring to each other in a loop
+	ruleUnusedProfile       = "unused-profile"       // a profile file nothing activates
+	ruleBootstrapExpression = "bootstrap-expression" // an expression in a bootstrap file, which is never evaluated
+	ruleProfileExpression   = "profile-expression"   // an on-profile expression that can't be parsed
+)
+
+// descriptions of the rules, for SARIF output
+var lintRules = map[string]string{
+	ruleInvalidFile:         "A property file that can't be read",
+	ruleLoadError:           "A problem loading the property files, such as a missing import",
+	ruleDuplicateKey:        "A key set more than once in the same file",
+	ruleShadowedKey:         "A key overridden by a file of the same name in a format of higher precedence",
+	ruleUndefinedReference:  "An expression referring to a property set in no file, with no default",
+	ruleUnusedDefault:       "A default for a property that is always set, so is never used",
+	ruleReferenceCycle:      "Properties referring to each other in a loop",
+	ruleUnusedProfile:       "A profile file for a profile that no file activates",
+	ruleBootstrapExpression: "An expression in a bootstrap file, where expressions are never evaluated",
+	ruleProfileExpression:   "An on-profile expression that can't be parsed, so what it applies to is never loaded",
+}
+
+// Finding a problem found by Lint
+type Finding struct {
+	Rule     string
+	Severity Severity
+	File     string
+	Line     int // 0 if not known
+	Key      string
+	Message  string
+}
+
+// String describe a finding on one line, e.g. resources/application.properties:3: warning: ... [duplicate-key]
+func (f Finding) String() string {
+	location := f.File
+	if f.Line > 0 {
+		location += ":" + strconv.Itoa(f.Line)
+	}
+	return fmt.Sprintf("%s: %s: %s [%s]", location, f.Severity, f.Message, f.Rule)
+}
+
+// Lint check the property files of a resources directory, and the directories below it, for likely mistakes
+//
+//   - keys set twice in the same file
+//   - keys in one file overridden by a file of the same name in another format, such as application.yaml and
+//     application.properties
+//   - ${} references to properties set in no file, and without a default
+//   - defaults for properties set in the application files, and so never used
+//   - properties referring to each other in a loop, with no profile or with any one profile that has a profile file
+//     or is named by the files, such as in an on-profile expression or a group
+//   - profile files for a profile no file activates, through the profile key, a group, an include or on-profile
+//   - expressions in bootstrap files, which are never evaluated
+//   - on-profile expressions that can't be parsed, so never match
+//
+// Only plain references, such as ${name} and ${prop:name:default}, are checked for undefined properties and unused
+// defaults. Arrays in JSON and YAML files are skipped: the loaders don't load their contents, so a key holding
+// an array counts as set, but any expressions inside it are never checked. The findings are sorted by file and
+// line. An error is returned if the directory can't be read
+func Lint(dir string) ([]Finding, error) {
+	l := &linter{dir: dir, lines: make(map[string]map[string]int), seen: make(map[string]bool)}
+	if err := l.scan(); err != nil {
+		return nil, err
+	}
+	l.checkShadowing()
+	l.checkReferences()
+	l.checkProfiles()
+	if !l.invalid {
+		l.checkConfigurations()
+	}
+	sort.SliceStable(l.findings, func(i, j int) bool {
+		a, b := l.findings[i], l.findings[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Rule < b.Rule
+	})
+	return l.findings, nil
+}
+
+// a key found in a property file
+type lintEntry struct {
+	key        string
+	value      string
+	line       int
+	document   int    // the yaml document holding the key, 0 for other formats
+	activation string // the on-profile expression of the yaml document, if any
+}
+
+// a property file found in the resources directory
+type lintFile struct {
+	path    string
+	name    string // the path without its extension
+	entries []lintEntry
+}
+
+type linter struct {
+	dir      string
+	files    []*lintFile
+	lines    map[string]map[string]int // the line of the first entry for each key, by file
+	invalid  bool                      // whether any file couldn't be read
+	findings []Finding
+	seen     map[string]bool // findings already made, so each is made once
+}
+
+func (l *linter) add(f Finding) {
+	if f.Line == 0 {
+		f.Line = l.lines[f.File][f.Key]
+	}
+	id := fmt.Sprint(f.Rule, f.File, f.Key, f.Message)
+	if !l.seen[id] {
+		l.seen[id] = true
+		l.findings = append(l.findings, f)
+	}
+}
+
+// read every property file in the directory, reporting keys set twice in the same file
+func (l *linter) scan() error {
+	if info, err := os.Stat(l.dir); err != nil {
+		return err
+	} else if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", l.dir)
+	}
+	return filepath.WalkDir(l.dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		var scan func([]byte) ([]lintEntry, error)
+		switch filepath.Ext(path) {
+		case ".properties":
+			scan = scanPropertiesFile
+		case ".yaml":
+			scan = scanYAMLFile
+		case ".json":
+			scan = scanJSONFile
+		default:
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err == nil {
+			var entries []lintEntry
+			if entries, err = scan(data); err == nil {
+				l.addFile(&lintFile{path: path, name: strings.TrimSuffix(path, filepath.Ext(path)), entries: entries})
+				return nil
+			}
+		}
+		l.invalid = true
+		l.add(Finding{Rule: ruleInvalidFile, Severity: SeverityError, File: path, Message: err.Error()})
+		return nil
+	})
+}
+
+func (l *linter) addFile(f *lintFile) {
+	l.files = append(l.files, f)
+	lines := make(map[string]int)
+	l.lines[f.path] = lines
+	first := make(map[string]int)
+	for _, entry := range f.entries {
+		if _, found := lines[entry.key]; !found {
+			lines[entry.key] = entry.line
+		}
+		id := strconv.Itoa(entry.document) + ":" + entry.key
+		if line, found := first[id]; found {
+			l.add(Finding{Rule: ruleDuplicateKey, Severity: SeverityWarning, File: f.path, Line: entry.line, Key: entry.key,
+				Message: fmt.Sprintf("%s is already set on line %d", entry.key, line)})
+		} else {
+			first[id] = entry.line
+		}
+	}
+}
+
+// report keys overridden by a file of the same name in a format of higher precedence
+func (l *linter) checkShadowing() {
+	precedence := make(map[string]int)
+	for i, extension := range defaultFormats {
+		precedence[extension] = i
+	}
+	byName := make(map[string][]*lintFile)
+	for _, f := range l.files {
+		byName[f.name] = append(byName[f.name], f)
+	}
+	for _, files := range byName {
+		sort.Slice(files, func(i, j int) bool {
+			return precedence[filepath.Ext(files[i].path)] < precedence[filepath.Ext(files[j].path)]
+		})
+		for i, f := range files {
+			for _, entry := range f.entries {
+				for _, higher := range files[i+1:] {
+					if _, found := l.lines[higher.path][entry.key]; found {
+						l.add(Finding{Rule: ruleShadowedKey, Severity: SeverityWarning, File: f.path, Line: entry.line,
+							Key: entry.key, Message: fmt.Sprintf("%s is overridden by %s", entry.key, higher.path)})
+						break
+					}
+				}
+			}
+		}
+	}
+}
+
+// whether a file is one of the application or bootstrap files, rather than a profile or imported file
+func (l *linter) isFile(f *lintFile, name string) bool {
+	return f.name == filepath.Join(l.dir, name)
+}
+
+// report references to properties set in no file, defaults that are never used, and expressions in bootstrap files
+func (l *linter) checkReferences() {
+	defined := make(map[string]bool) // set in any file
+	always := make(map[string]bool)  // set in the bootstrap files, or unconditionally in the application files
+	for _, f := range l.files {
+		for _, entry := range f.entries {
+			defined[entry.key] = true
+			if l.isFile(f, "bootstrap") || l.isFile(f, "application") && entry.activation == "" {
+				always[entry.key] = true
+			}
+		}
+	}
+	p := EmptyProperties()
+	for _, f := range l.files {
+		for _, entry := range f.entries {
+			if !containsExpression(entry.value) {
+				continue
+			}
+			if l.isFile(f, "bootstrap") {
+				l.add(Finding{Rule: ruleBootstrapExpression, Severity: SeverityWarning, File: f.path, Line: entry.line,
+					Key: entry.key, Message: fmt.Sprintf("%s holds an expression, but bootstrap properties are never evaluated", entry.key)})
+				continue
+			}
+			for element := extractExpressions(entry.value).Front(); element != nil; element = element.Next() {
+				name, hasDefault, plain := p.plainReference(element.Value.(*exprParts))
+				switch {
+				case !plain:
+				case !hasDefault && !defined[name]:
+					l.add(Finding{Rule: ruleUndefinedReference, Severity: SeverityError, File: f.path, Line: entry.line,
+						Key: entry.key, Message: fmt.Sprintf("%s refers to %s, which is not set in any file", entry.key, name)})
+				case hasDefault && always[name]:
+					l.add(Finding{Rule: ruleUnusedDefault, Severity: SeverityWarning, File: f.path, Line: entry.line,
+						Key: entry.key, Message: fmt.Sprintf("%s gives a default for %s, which is always set", entry.key, name)})
+				}
+			}
+		}
+	}
+}
+
+// the property named by a plain reference, ${name}, ${prop:name:default} or, with lenient namespaces,
+// ${name:default}, and whether it has a default. plain is false for other expressions, and for lookups in other
+// namespaces
+func (p *Properties) plainReference(item *exprParts) (name string, hasDefault bool, plain bool) {
+	if item.expr != nil || item.err != nil {
+		return "", false, false
+	}
+	if item.namespaced() {
+		namespace, err := p.namespace(item.name)
+		if err != nil {
+			return "", false, false
+		}
+		if namespace != nil {
+			if item.name != propNamespace {
+				return "", false, false
+			}
+			key, _ := namespaceKey(item.defaultValue)
+			return key, strings.Contains(item.defaultValue, ":"), true
+		}
+		return item.name, true, true
+	}
+	return item.name, false, true
+}
+
+// the names of the profiles with a profile file, such as prod for application_prod.yaml
+func (l *linter) profileFiles() map[string]*lintFile {
+	profiles := make(map[string]*lintFile)
+	prefix := filepath.Join(l.dir, "application") + "_"
+	for _, f := range l.files {
+		if strings.HasPrefix(f.name, prefix) {
+			if _, found := profiles[strings.TrimPrefix(f.name, prefix)]; !found {
+				profiles[strings.TrimPrefix(f.name, prefix)] = f
+			}
+		}
+	}
+	return profiles
+}
+
+// report profile files for profiles no file activates, and on-profile expressions that can't be parsed
+func (l *linter) checkProfiles() {
+	for _, f := range l.files {
+		for _, entry := range f.entries {
+			expression := entryActivation(entry)
+			if _, err := matchProfiles(expression, nil); expression != "" && err != nil {
+				l.add(Finding{Rule: ruleProfileExpression, Severity: SeverityError, File: f.path, Line: entry.line,
+					Message: fmt.Sprintf("invalid profile expression %s: %v, so it never matches", expression, err)})
+			}
+		}
+	}
+	activated := l.activatedProfiles()
+	for name, f := range l.profileFiles() {
+		if !activated[name] {
+			l.add(Finding{Rule: ruleUnusedProfile, Severity: SeverityNote, File: f.path, Line: 1,
+				Message: fmt.Sprintf("no file activates profile %s, so it is only used if chosen on the command line, "+
+					"in the environment or in code", name)})
+		}
+	}
+}
+
+// the profiles the files name: the default profile, those chosen with the profile key, included or grouped, and
+// those in on-profile expressions
+func (l *linter) activatedProfiles() map[string]bool {
+	activated := map[string]bool{defaultProfile: true}
+	for _, f := range l.files {
+		for _, entry := range f.entries {
+			switch {
+			case entry.key == profileKey || entry.key == profileIncludeKey:
+				for _, name := range splitList(entry.value) {
+					activated[name] = true
+				}
+			case strings.HasPrefix(entry.key, profileGroupPrefix):
+				activated[strings.TrimPrefix(entry.key, profileGroupPrefix)] = true
+				for _, name := range splitList(entry.value) {
+					activated[name] = true
+				}
+			}
+			for _, token := range profileTokens(entryActivation(entry)) {
+				if !strings.ContainsAny(token, "!&|()") {
+					activated[token] = true
+				}
+			}
+		}
+	}
+	return activated
+}
+
+// the on-profile expression a key is loaded under, from its yaml document or, for the key itself, its file
+func entryActivation(entry lintEntry) string {
+	if entry.key == profileActivationKey {
+		return entry.value
+	}
+	return entry.activation
+}
+
+// load the files as the application would, with no profile and with each profile in turn, reporting problems
+// loading them and properties referring to each other in a loop. the profiles are those with a profile file and
+// those the files name, see activatedProfiles, so conditional content is checked too
+func (l *linter) checkConfigurations() {
+	base := EmptyProperties()
+	BootPropertyLoader(filepath.Join(l.dir, "bootstrap"))(base)
+	base.operations = []func(p *Properties){GlobalPropertyLoader(filepath.Join(l.dir, "application"))}
+	configurations := []*Properties{base}
+	profiles := l.activatedProfiles()
+	for name := range l.profileFiles() {
+		profiles[name] = true
+	}
+	names := []string{}
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		p := DirectoryProperties(l.dir)
+		p.SetProfiles(name)
+		configurations = append(configurations, p)
+	}
+	for _, p := range configurations {
+		p.SetLogger(discardLogger{})
+		p.SetEvaluators()
+		if err := p.Load(); err != nil {
+			for _, e := range err.(*LoadError).Errors {
+				if errors.Is(e, errProfileExpression) {
+					continue // reported by checkProfiles
+				}
+				l.add(Finding{Rule: ruleLoadError, Severity: SeverityError, File: l.dir, Message: e.Error()})
+			}
+		}
+		for _, cycle := range referenceCycles(p) {
+			l.add(Finding{Rule: ruleReferenceCycle, Severity: SeverityError, File: p.sources[cycle[0]], Key: cycle[0],
+				Message: "property cycle " + strings.Join(cycle, " -> ")})
+		}
+	}
+}
+
+// a Logger that writes nothing
+type discardLogger struct{}
+
+func (discardLogger) Printf(string, ...interface{}) {}
+
+// the loops of properties referring to each other, each starting from its first key in sorted order
+func referenceCycles(p *Properties) [][]string {
+	values := p.rawValues()
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	cycles := [][]string{}
+	state := make(map[string]int)
+	var visit func(key string, path []string)
+	visit = func(key string, path []string) {
+		switch state[key] {
+		case visited:
+			return
+		case visiting:
+			for i, name := range path {
+				if name == key {
+					cycles = append(cycles, rotateCycle(append(append([]string{}, path[i:]...), key)))
+				}
+			}
+			return
+		}
+		state[key] = visiting
+		for _, name := range p.references(values[key]) {
+			if _, found := values[name]; found {
+				visit(name, append(path, key))
+			}
+		}
+		state[key] = visited
+	}
+	for _, key := range keys {
+		visit(key, nil)
+	}
+	return cycles
+}
+
+// rotate a cycle, a -> b -> a, so it starts with its first key in sorted order
+func rotateCycle(cycle []string) []string {
+	loop := cycle[:len(cycle)-1]
+	first := 0
+	for i, name := range loop {
+		if name < loop[first] {
+			first = i
+		}
+	}
+	rotated := append(append([]string{}, loop[first:]...), loop[:first]...)
+	return append(rotated, rotated[0])
+}
+
+// the keys of a .properties file, read as loadPropertiesFromFile reads them
+func scanPropertiesFile(data []byte) ([]lintEntry, error) {
+	entries := []lintEntry{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimLeft(scanner.Text(), " \t")
+		if line == "" || line[0] == '#' || line[0] == '!' {
+			continue
+		}
+		separator := propertySeparator(line)
+		if separator < 0 {
+			return nil, fmt.Errorf("line %d: no = in %s", number, line)
+		}
+		entries = append(entries, lintEntry{
+			key:   strings.Trim(unescapeProperty(line[:separator]), " \t"),
+			value: strings.Trim(unescapeProperty(line[separator+1:]), " \t"),
+			line:  number,
+		})
+	}
+	return entries, scanner.Err()
+}
+
+// the keys of a yaml file, flattened as extractYAMLNode flattens them, for each document
+func scanYAMLFile(data []byte) ([]lintEntry, error) {
+	entries := []lintEntry{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	for document := 0; ; document++ {
+		var node yaml.Node
+		err := decoder.Decode(&node)
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(node.Content) == 0 {
+			continue
+		}
+		root := node.Content[0]
+		if root.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("line %d: expected a mapping at the top level", root.Line)
+		}
+		activation, _ := yamlActivation(root)
+		var walk func(node *yaml.Node, prefix string)
+		walk = func(node *yaml.Node, prefix string) {
+			for _, pair := range yamlPairs(node) {
+				if prefix == "" && pair[0].Value == profileActivationKey {
+					continue
+				}
+				name := prefix + pair[0].Value
+				if pair[1].Kind == yaml.MappingNode {
+					walk(pair[1], name+".")
+					continue
+				}
+				entries = append(entries, lintEntry{key: name, value: pair[1].Value, line: pair[0].Line,
+					document: document, activation: activation})
+			}
+		}
+		walk(root, "")
+	}
+}
+
+// the keys of a json file, flattened as extractKVMap flattens them. unlike decoding into a map, this sees keys
+// that are repeated
+func scanJSONFile(data []byte) ([]lintEntry, error) {
+	entries := []lintEntry{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	line := func() int {
+		offset := int(decoder.InputOffset())
+		if next := bytes.IndexByte(data[offset:], '"'); next >= 0 {
+			offset += next
+		}
+		return bytes.Count(data[:offset], []byte("\n")) + 1
+	}
+	var object func(prefix string) error
+	object = func(prefix string) error {
+		for decoder.More() {
+			number := line()
+			token, err := decoder.Token()
+			if err != nil {
+				return err
+			}
+			name := prefix + token.(string)
+			if token, err = decoder.Token(); err != nil {
+				return err
+			}
+			value := ""
+			switch v := token.(type) {
+			case json.Delim:
+				if v == '{' {
+					if err := object(name + "."); err != nil {
+						return err
+					}
+					continue
+				}
+				for depth := 1; depth > 0; { // skip an array
+					if token, err = decoder.Token(); err != nil {
+						return err
+					}
+					if d, isDelim := token.(json.Delim); isDelim {
+						if d == '[' || d == '{' {
+							depth++
+						} else {
+							depth--
+						}
+					}
+				}
+				value = "???" // as the loader holds it, so expressions inside are not checked
+			case nil:
+			default:
+				value = fmt.Sprint(v)
+			}
+			entries = append(entries, lintEntry{key: name, value: value, line: number})
+		}
+		_, err := decoder.Token() // the closing }
+		return err
+	}
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	if token != json.Delim('{') {
+		return nil, fmt.Errorf("expected an object at the top level")
+	}
+	if err := object(""); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// LintFormat an output format for WriteFindings
+type LintFormat string
+
+const (
+	LintText  LintFormat = "text"  // one finding per line
+	LintJSON  LintFormat = "json"  // a json array of findings
+	LintSARIF LintFormat = "sarif" // SARIF 2.1.0, as read by code review and code scanning tools
+)
+
+// WriteFindings write lint findings out as text, json or SARIF
+func WriteFindings(w io.Writer, findings []Finding, format LintFormat) error {
+	switch format {
+	case LintText:
+		for _, f := range findings {
+			if _, err := fmt.Fprintln(w, f); err != nil {
+				return err
+			}
+		}
+		return nil
+	case LintJSON:
+		type jsonFinding struct {
+			Rule     string   `json:"rule"`
+			Severity Severity `json:"severity"`
+			File     string   `json:"file"`
+			Line     int      `json:"line,omitempty"`
+			Key      string   `json:"key,omitempty"`
+			Message  string   `json:"message"`
+		}
+		list := make([]jsonFinding, len(findings))
+		for i, f := range findings {
+			list[i] = jsonFinding(f)
+		}
+		return writeIndentedJSON(w, list)
+	case LintSARIF:
+		return writeIndentedJSON(w, sarifLog(findings))
+	}
+	return fmt.Errorf("findings can't be written as %s", format)
+}
+
+func writeIndentedJSON(w io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// findings as a SARIF 2.1.0 log, with one run
+func sarifLog(findings []Finding) map[string]interface{} {
+	ids := make([]string, 0, len(lintRules))
+	for id := range lintRules {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	rules := []map[string]interface{}{}
+	for _, id := range ids {
+		rules = append(rules, map[string]interface{}{"id": id, "shortDescription": map[string]string{"text": lintRules[id]}})
+	}
+	results := []map[string]interface{}{}
+	for _, f := range findings {
+		region := map[string]interface{}{}
+		if f.Line > 0 {
+			region["startLine"] = f.Line
+		}
+		location := map[string]interface{}{"artifactLocation": map[string]string{"uri": filepath.ToSlash(f.File)}}
+		if len(region) > 0 {
+			location["region"] = region
+		}
+		results = append(results, map[string]interface{}{
+			"ruleId":    f.Rule,
+			"level":     string(f.Severity),
+			"message":   map[string]string{"text": f.Message},
+			"locations": []map[string]interface{}{{"physicalLocation": location}},
+		})
+	}
+	return map[string]interface{}{
+		"$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+		"version": "2.1.0",
+		"runs": []map[string]interface{}{{
+			"tool": map[string]interface{}{"driver": map[string]interface{}{
+				"name":           "props lint",
+				"informationUri": "https://github.com/codesqueak/simpleProperties",
+				"rules":          rules,
+			}},
+			"results": results,
+		}},
+	}
+}
@@ -0,0 +1,142 @@
+package simpleProperties
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	findings, err := Lint("testdata/resources/lint")
+	if err != nil {
+		t.Fatalf("Lint() error = %v", err)
+	}
+	out := &bytes.Buffer{}
+	if err := WriteFindings(out, findings, LintText); err != nil {
+		t.Fatalf("WriteFindings() error = %v", err)
+	}
+	want := []string{
+		"testdata/resources/lint/application.json:3: warning: name is already set on line 2 [duplicate-key]",
+		"testdata/resources/lint/application.properties:3: warning: server.port is already set on line 2 [duplicate-key]",
+		"testdata/resources/lint/application.properties:4: error: server.url refers to server.host, which is not set in any file [undefined-reference]",
+		"testdata/resources/lint/application.properties:5: warning: timeout gives a default for server.port, which is always set [unused-default]",
+		"testdata/resources/lint/application.properties:7: error: property cycle a -> b -> a [reference-cycle]",
+		"testdata/resources/lint/application.yaml:2: warning: server.port is overridden by testdata/resources/lint/application.properties [shadowed-key]",
+		"testdata/resources/lint/application.yaml:7: error: invalid profile expression staging & (eu: missing ), so it never matches [profile-expression]",
+		"testdata/resources/lint/application_metrics.properties:2: error: property cycle metrics.host -> metrics.url -> metrics.host [reference-cycle]",
+		"testdata/resources/lint/application_prod.properties:1: note: no file activates profile prod, so it is only used if chosen on the command line, in the environment or in code [unused-profile]",
+		"testdata/resources/lint/bootstrap.properties:1: warning: app.home holds an expression, but bootstrap properties are never evaluated [bootstrap-expression]",
+	}
+	if got := strings.Split(strings.TrimSpace(out.String()), "\n"); !reflect.DeepEqual(got, want) {
+		t.Errorf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// a tree with profiles chosen only from outside has nothing worse than notes to report
+	findings, err = Lint("testdata/resources/diff")
+	if err != nil {
+		t.Fatalf("Lint() error = %v", err)
+	}
+	for _, f := range findings {
+		if f.Severity != SeverityNote {
+			t.Errorf("unexpected finding %s", f)
+		}
+	}
+	// cycles in content loaded only for profiles named in on-profile expressions and groups
+	findings, err = Lint("testdata/resources/lint-profiles")
+	if err != nil {
+		t.Fatalf("Lint() error = %v", err)
+	}
+	got := []string{}
+	for _, f := range findings {
+		got = append(got, f.String())
+	}
+	want = []string{
+		"testdata/resources/lint-profiles/application.properties:1: error: property cycle a -> b -> a [reference-cycle]",
+		"testdata/resources/lint-profiles/application.yaml:7: error: property cycle c -> d -> c [reference-cycle]",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	if _, err := Lint("testdata/resources/nothing"); err == nil {
+		t.Errorf("expected an error for a missing directory")
+	}
+}
+
+func TestLintFormats(t *testing.T) {
+	findings := []Finding{
+		{Rule: ruleDuplicateKey, Severity: SeverityWarning, File: "resources/application.properties", Line: 3, Key: "port", Message: "port is already set on line 2"},
+		{Rule: ruleLoadError, Severity: SeverityError, File: "resources", Message: "import missing.yaml: not found"},
+	}
+	out := &bytes.Buffer{}
+	if err := WriteFindings(out, findings, LintJSON); err != nil {
+		t.Fatalf("WriteFindings(json) error = %v", err)
+	}
+	var list []map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &list); err != nil {
+		t.Fatalf("invalid json %v\n%s", err, out)
+	}
+	if len(list) != 2 || list[0]["rule"] != ruleDuplicateKey || list[0]["line"] != 3.0 || list[1]["line"] != nil {
+		t.Errorf("unexpected json %s", out)
+	}
+
+	out.Reset()
+	if err := WriteFindings(out, findings, LintSARIF); err != nil {
+		t.Fatalf("WriteFindings(sarif) error = %v", err)
+	}
+	var sarif struct {
+		Version string
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Rules []struct{ ID string }
+				}
+			}
+			Results []struct {
+				RuleID    string
+				Level     string
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct{ URI string }
+						Region           struct{ StartLine int }
+					}
+				}
+			}
+		}
+	}
+	if err := json.Unmarshal(out.Bytes(), &sarif); err != nil {
+		t.Fatalf("invalid sarif %v\n%s", err, out)
+	}
+	if sarif.Version != "2.1.0" || len(sarif.Runs) != 1 || len(sarif.Runs[0].Tool.Driver.Rules) != len(lintRules) {
+		t.Fatalf("unexpected sarif %s", out)
+	}
+	result := sarif.Runs[0].Results[0]
+	location := result.Locations[0].PhysicalLocation
+	if result.RuleID != ruleDuplicateKey || result.Level != "warning" ||
+		location.ArtifactLocation.URI != "resources/application.properties" || location.Region.StartLine != 3 {
+		t.Errorf("unexpected sarif result %+v", result)
+	}
+
+	if err := WriteFindings(out, findings, "xml"); err == nil {
+		t.Errorf("expected an error for an unknown format")
+	}
+}
+
+func Test_scanJSONFile(t *testing.T) {
+	data := []byte("{\n  \"a\": 1,\n  \"b\": {\n    \"c\": [1, {\"x\": 2}],\n    \"d\": null\n  },\n  \"a\": \"again\"\n}\n")
+	want := []lintEntry{
+		{key: "a", value: "1", line: 2},
+		{key: "b.c", value: "???", line: 4},
+		{key: "b.d", value: "", line: 5},
+		{key: "a", value: "again", line: 7},
+	}
+	got, err := scanJSONFile(data)
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("scanJSONFile() = %+v, %v, want %+v", got, err, want)
+	}
+	if _, err := scanJSONFile([]byte("[1, 2]")); err == nil {
+		t.Errorf("expected an error for a json array")
+	}
+}
@@ -0,0 +1,137 @@
+package simpleProperties
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// namespaces known to every Properties instance
+const (
+	bootNamespace = "boot" // ${boot:key}, a bootstrap property
+	envNamespace  = "env"  // ${env:HOME}, an O/S environment variable
+	cliNamespace  = "cli"  // ${cli:port}, a -key=value CLI parameter
+	sysNamespace  = "sys"  // ${sys:hostname}, a fact about the system, see sysValue
+	propNamespace = "prop" // ${prop:key}, an application property, never a bootstrap one
+)
+
+// Resolver looks up keys in a namespace, such as env in ${env:HOME}
+type Resolver interface {
+	// Resolve the value of a key, and whether it has one
+	Resolve(key string) (string, bool)
+}
+
+// ResolverFunc lets an ordinary function be used as a Resolver
+type ResolverFunc func(key string) (string, bool)
+
+// Resolve call f(key)
+func (f ResolverFunc) Resolve(key string) (string, bool) {
+	return f(key)
+}
+
+// RegisterResolver make a namespace available to expressions, so ${namespace:key} is looked up with the
+// resolver. A registered namespace replaces a built in one of the same name, except prop, and a nil resolver
+// removes a namespace. ${namespace:key:default} gives default when the resolver has no value for the key
+func (p *Properties) RegisterResolver(namespace string, r Resolver) {
+	if p.resolvers == nil {
+		p.resolvers = make(map[string]Resolver)
+	}
+	p.resolvers[namespace] = r
+}
+
+// SetLenientNamespaces choose how ${a:b} is read when a is not a namespace. By default it is reported as an unknown
+// namespace, and a default is written with the prop namespace, ${prop:a:b}. In lenient mode it is a reference to the
+// property a, with the default b, as it was before namespaces were added
+func (p *Properties) SetLenientNamespaces(lenient bool) {
+	p.lenientNamespaces = lenient
+}
+
+// the resolver for a namespace. nil, with no error, when the name is not a namespace and namespaces are lenient,
+// so it names a property
+func (p *Properties) namespace(name string) (Resolver, error) {
+	if r, found := p.resolvers[name]; found && name != propNamespace {
+		if r == nil {
+			return p.unknownNamespace(name)
+		}
+		return r, nil
+	}
+	switch name {
+	case bootNamespace:
+		return ResolverFunc(func(key string) (string, bool) {
+			v, found := p.bootKeyValueMap[p.storedKey(key)]
+			return v, found
+		}), nil
+	case envNamespace:
+		return ResolverFunc(os.LookupEnv), nil
+	case cliNamespace:
+		return ResolverFunc(func(key string) (string, bool) {
+			v, found := "", false
+			for _, kv := range p.cliParameters(os.Args) {
+				if kv[0] == key {
+					v, found = kv[1], true // the last one wins, as when loading
+				}
+			}
+			return v, found
+		}), nil
+	case sysNamespace:
+		return ResolverFunc(sysValue), nil
+	case propNamespace:
+		return ResolverFunc(func(key string) (string, bool) {
+			v, found := p.keyValueMap[p.storedKey(key)]
+			return v, found
+		}), nil
+	}
+	return p.unknownNamespace(name)
+}
+
+// an error for a name that is not a namespace, unless namespaces are lenient
+func (p *Properties) unknownNamespace(name string) (Resolver, error) {
+	if p.lenientNamespaces {
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unknown namespace %s", name)
+}
+
+// facts about the system for the sys namespace: hostname, os, arch, pid, cpus, go.version, tmpdir, user.name,
+// user.home and user.dir, the working directory
+func sysValue(key string) (string, bool) {
+	var v string
+	var err error
+	switch key {
+	case "hostname":
+		v, err = os.Hostname()
+	case "os":
+		v = runtime.GOOS
+	case "arch":
+		v = runtime.GOARCH
+	case "pid":
+		v = strconv.Itoa(os.Getpid())
+	case "cpus":
+		v = strconv.Itoa(runtime.NumCPU())
+	case "go.version":
+		v = runtime.Version()
+	case "tmpdir":
+		v = os.TempDir()
+	case "user.name":
+		var u *user.User
+		if u, err = user.Current(); err == nil {
+			v = u.Username
+		}
+	case "user.home":
+		v, err = os.UserHomeDir()
+	case "user.dir":
+		v, err = os.Getwd()
+	default:
+		return "", false
+	}
+	return v, err == nil
+}
+
+// split the key of a namespaced reference from its default, as in ${env:HOME:/root}
+func namespaceKey(reference string) (string, string) {
+	key, defaultValue, _ := strings.Cut(reference, ":")
+	return key, defaultValue
+}
@@ -0,0 +1,138 @@
+package simpleProperties
+
+import (
+	"os"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestNamespaces(t *testing.T) {
+	t.Setenv("SIMPLE_PROPERTIES_TEST", "from env")
+	args := os.Args
+	defer func() { os.Args = args }()
+	os.Args = []string{"app", "-port=9090"}
+
+	p := EmptyProperties()
+	p.SetEvaluators(DefaultEvaluator())
+	p.bootKeyValueMap["application.name"] = "billing"
+	p.bootKeyValueMap["shared"] = "boot value"
+	p.RegisterResolver("vault", ResolverFunc(func(key string) (string, bool) {
+		if key == "db/password" {
+			return "s3cret", true
+		}
+		return "", false
+	}))
+	p.Merge(map[string]string{
+		"env":          "a property called env",
+		"name":         "${boot:application.name}",
+		"home":         "${env:SIMPLE_PROPERTIES_TEST}",
+		"missing.env":  "${env:SIMPLE_PROPERTIES_NOT_SET:none}",
+		"port":         "${cli:port}",
+		"os":           "${sys:os}",
+		"unknown.sys":  "${sys:nothing:n/a}",
+		"chained":      "${prop:url}",
+		"url":          "http://${prop:host:localhost}:${port}",
+		"boot.only":    "${prop:shared:not an application property}",
+		"merged":       "${shared}",
+		"plain":        "${env}",
+		"default":      "${prop:undefined:fallback}",
+		"password":     "${vault:db/password}",
+		"no.secret":    "${vault:other:unset}",
+		"unresolvable": "${vault:other}",
+	})
+	err := p.Evaluate()
+	if err != nil {
+		t.Fatalf("Evaluate() error = %v", err)
+	}
+	want := map[string]string{
+		"name":         "billing",
+		"home":         "from env",
+		"missing.env":  "none",
+		"port":         "9090",
+		"os":           runtime.GOOS,
+		"unknown.sys":  "n/a",
+		"chained":      "http://localhost:9090",
+		"url":          "http://localhost:9090",
+		"boot.only":    "not an application property",
+		"merged":       "boot value",
+		"plain":        "a property called env",
+		"default":      "fallback",
+		"password":     "s3cret",
+		"no.secret":    "unset",
+		"unresolvable": "",
+	}
+	for k, v := range want {
+		if got := p.GetProperty(k); got != v {
+			t.Errorf("GetProperty(%s) = %v, want %v", k, got, v)
+		}
+	}
+	if got := p.DependsOn("url"); !reflect.DeepEqual(got, []string{"host", "port"}) {
+		t.Errorf("DependsOn(url) = %v", got)
+	}
+	if got := p.DependsOn("home"); len(got) != 0 {
+		t.Errorf("DependsOn(home) = %v, want none", got)
+	}
+}
+
+func TestRegisterResolverReplacesBuiltIn(t *testing.T) {
+	p := EmptyProperties()
+	p.SetEvaluators(DefaultEvaluator())
+	p.RegisterResolver("env", ResolverFunc(func(key string) (string, bool) { return "fixed " + key, true }))
+	p.RegisterResolver("sys", nil)
+	p.SetLenientNamespaces(true) // so ${sys:os} is the property sys, with a default
+	p.RegisterResolver("prop", ResolverFunc(func(key string) (string, bool) { return "ignored", true }))
+	p.Merge(map[string]string{"a": "${env:HOME}", "b": "${sys:os}", "c": "${prop:a}", "sys": "S"})
+	if err := p.Evaluate(); err != nil {
+		t.Fatalf("Evaluate() error = %v", err)
+	}
+	for k, v := range map[string]string{"a": "fixed HOME", "b": "S", "c": "fixed HOME"} {
+		if got := p.GetProperty(k); got != v {
+			t.Errorf("GetProperty(%s) = %v, want %v", k, got, v)
+		}
+	}
+}
+
+func TestUnknownNamespaces(t *testing.T) {
+	p := EmptyProperties()
+	p.SetEvaluators(DefaultEvaluator())
+	p.Merge(map[string]string{"a": "${nosuch:key}", "b": "${prop:c:default}", "c": ""})
+	err := p.Evaluate()
+	if err == nil || !strings.Contains(err.Error(), "a: expression ${nosuch:key}: unknown namespace nosuch") {
+		t.Errorf("Evaluate() error = %v", err)
+	}
+	if got := p.GetProperty("b"); got != "" {
+		t.Errorf("GetProperty(b) = %v, want the empty value of c", got)
+	}
+	if got := p.GetEvalProperty("a"); got != "${nosuch:key}" {
+		t.Errorf("GetEvalProperty(a) = %v", got)
+	}
+
+	// leniently, it is the property nosuch, with a default
+	p.SetLenientNamespaces(true)
+	if err := p.Evaluate(); err != nil {
+		t.Fatalf("Evaluate() error = %v", err)
+	}
+	if got := p.GetProperty("a"); got != "key" {
+		t.Errorf("GetProperty(a) = %v, want the default", got)
+	}
+}
+
+func TestNamespacesFollowKeys(t *testing.T) {
+	p := EmptyProperties()
+	p.SetEvaluators(DefaultEvaluator())
+	p.SetRelaxedKeys(true)
+	p.Alias("server.host", "host")
+	p.bootKeyValueMap["app.home-dir"] = "/opt/app"
+	p.Merge(map[string]string{"host": "example.com", "db.pool-size": "5",
+		"home": "${boot:app.homeDir}", "url": "${prop:server.host}", "pool": "${prop:DB_POOL_SIZE}"})
+	if err := p.Evaluate(); err != nil {
+		t.Fatalf("Evaluate() error = %v", err)
+	}
+	for key, want := range map[string]string{"home": "/opt/app", "url": "example.com", "pool": "5"} {
+		if got := p.GetProperty(key); got != want {
+			t.Errorf("GetProperty(%s) = %q, want %q", key, got, want)
+		}
+	}
+}
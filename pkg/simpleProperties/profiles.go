@@ -0,0 +1,279 @@
+package simpleProperties
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const profileKey = "profile"
+const profileEnvironment = "APP_PROFILE"
+const defaultProfile = "default"
+const profileGroupPrefix = "profile.group." // profile.group.prod=prod-db,metrics activates prod-db & metrics with prod
+const profileIncludeKey = "include"         // include=common,logging activates common & logging
+
+// SetProfileKey set the name of the property holding the comma separated list of profiles, e.g. spring.profiles.active.
+// the same name is used for the -key=value CLI parameter. by default the key is profile
+func (p *Properties) SetProfileKey(key string) {
+	p.profileKey = strings.Trim(key, " \t")
+}
+
+// GetProfileKey get the name of the property holding the profile names
+func (p *Properties) GetProfileKey() string {
+	if p.profileKey == "" {
+		return profileKey
+	}
+	return p.profileKey
+}
+
+// SetDefaultProfile set the profile(s) made active when no profile is set by any other means. calling with no
+// names means there is no default profile. unless changed, the default profile is named default
+func (p *Properties) SetDefaultProfile(names ...string) {
+	p.defaultProfiles = cleanList(names)
+}
+
+// SetProfiles choose the active profiles, overriding any profile set on the command line, in the environment
+// or in the property files. this must be called before Load
+func (p *Properties) SetProfiles(names ...string) {
+	p.setProfiles = cleanList(names)
+}
+
+// ActiveProfiles get the active profiles in the order they were loaded. This is empty until the profile loader
+// has run as part of Load. The profiles are taken from the first of these to name any profile
+//
+// 1. profiles given to SetProfiles
+// 2. the -profile=a,b CLI parameter
+// 3. the APP_PROFILE environment variable
+// 4. the profile property, as loaded from the bootstrap and application files
+// 5. the default profile
+func (p *Properties) ActiveProfiles() []string {
+	return append([]string{}, p.profiles...)
+}
+
+// choose the active profiles, see ActiveProfiles
+func selectProfiles(p *Properties) []string {
+	if len(p.setProfiles) > 0 {
+		return append([]string{}, p.setProfiles...)
+	}
+	key := p.GetProfileKey()
+	var cli []string
+	for _, kv := range p.cliParameters(os.Args) {
+		if strings.Trim(kv[0], " \t") == key {
+			cli = splitList(kv[1]) // last one wins, as with any other CLI parameter
+		}
+	}
+	if len(cli) > 0 {
+		return cli
+	}
+	if env := splitList(os.Getenv(profileEnvironment)); len(env) > 0 {
+		return env
+	}
+	if files := splitList(p.GetProperty(key)); len(files) > 0 {
+		return files
+	}
+	if p.defaultProfiles == nil {
+		return []string{defaultProfile}
+	}
+	return append([]string{}, p.defaultProfiles...)
+}
+
+// expand the selected profiles into the full, ordered list of profiles to load. each profile is replaced by
+//
+// 1. the profiles named by the include key in its application_<profile> files, expanded in turn
+// 2. the profile itself
+// 3. the members of its group, profile.group.<profile>, expanded in turn
+//
+// the include key of the application files works in the same way for the selected profiles as a whole, so
+// these profiles are loaded first. a profile is only loaded once, and a cycle of groups or includes is
+// reported and broken
+func expandProfiles(p *Properties, path string, selected []string) []string {
+	expanded := []string{}
+	done := make(map[string]bool)
+	inProgress := make(map[string]bool)
+	var expand func(name string)
+	expand = func(name string) {
+		if inProgress[name] {
+			p.logf("Profile %s includes itself through a group or include, ignored", name)
+			return
+		}
+		if done[name] {
+			return
+		}
+		inProgress[name] = true
+		for _, included := range profileIncludes(p, path+"_"+name) {
+			expand(included)
+		}
+		if !done[name] {
+			expanded = append(expanded, name)
+			done[name] = true
+		}
+		for _, member := range splitList(p.GetProperty(profileGroupPrefix + name)) {
+			expand(member)
+		}
+		delete(inProgress, name)
+	}
+	for _, name := range splitList(p.GetProperty(profileIncludeKey)) {
+		expand(name)
+	}
+	for _, name := range selected {
+		expand(name)
+	}
+	return expanded
+}
+
+// find the profiles included by a profile's files. the files are read into a scratch properties
+// structure, as the profiles they activate are needed before they are loaded for real
+func profileIncludes(p *Properties, path string) []string {
+	scratch := &Properties{
+		keyValueMap:     make(map[string]string),
+		evalKeyValueMap: make(map[string]string),
+		evalExprMap:     make(map[string]*list.List),
+		formats:         p.formats,
+	}
+	baseLoader(scratch, path)
+	return splitList(scratch.keyValueMap[profileIncludeKey])
+}
+
+// the error recorded for an on-profile expression that can't be parsed
+var errProfileExpression = errors.New("invalid profile expression")
+
+// check a profile expression against the active profiles, see matchProfiles. an invalid expression never matches,
+// and is recorded as an error of the properties, naming where it was found
+func profileActive(p *Properties, source string, expression string) bool {
+	match, err := matchProfiles(expression, p.profiles)
+	if err != nil {
+		p.errs = append(p.errs, fmt.Errorf("%s: %w %s: %v", source, errProfileExpression, expression, err))
+		return false
+	}
+	return match
+}
+
+// match a profile expression against a list of active profiles. an expression is a profile name, or combines
+// expressions with ! (not), & (and), | (or) and parentheses, with & binding tighter than |. a comma separated
+// list of expressions matches if any of them match, so "dev, prod & !eu" matches dev, or prod outside eu
+func matchProfiles(expression string, active []string) (bool, error) {
+	match := false
+	for _, part := range splitList(expression) {
+		parser := &profileParser{tokens: profileTokens(part), active: active}
+		m, err := parser.or()
+		if err != nil {
+			return false, err
+		}
+		if parser.pos < len(parser.tokens) {
+			return false, fmt.Errorf("unexpected %s", parser.tokens[parser.pos])
+		}
+		match = match || m
+	}
+	return match, nil
+}
+
+// split a profile expression into names and the operators ! & | ( )
+func profileTokens(expression string) []string {
+	tokens := []string{}
+	name := strings.Builder{}
+	endName := func() {
+		if name.Len() > 0 {
+			tokens = append(tokens, name.String())
+			name.Reset()
+		}
+	}
+	for _, c := range expression {
+		switch c {
+		case '!', '&', '|', '(', ')':
+			endName()
+			tokens = append(tokens, string(c))
+		case ' ', '\t':
+			endName()
+		default:
+			name.WriteRune(c)
+		}
+	}
+	endName()
+	return tokens
+}
+
+// recursive descent parser for profile expressions
+//
+//	or   := and { '|' and }
+//	and  := not { '&' not }
+//	not  := '!' not | '(' or ')' | name
+type profileParser struct {
+	tokens []string
+	pos    int
+	active []string
+}
+
+func (pp *profileParser) or() (bool, error) {
+	match, err := pp.and()
+	for err == nil && pp.next("|") {
+		var m bool
+		m, err = pp.and()
+		match = match || m
+	}
+	return match, err
+}
+
+func (pp *profileParser) and() (bool, error) {
+	match, err := pp.not()
+	for err == nil && pp.next("&") {
+		var m bool
+		m, err = pp.not()
+		match = match && m
+	}
+	return match, err
+}
+
+func (pp *profileParser) not() (bool, error) {
+	if pp.pos >= len(pp.tokens) {
+		return false, fmt.Errorf("missing profile name")
+	}
+	token := pp.tokens[pp.pos]
+	pp.pos++
+	switch token {
+	case "!":
+		match, err := pp.not()
+		return !match, err
+	case "(":
+		match, err := pp.or()
+		if err == nil && !pp.next(")") {
+			err = fmt.Errorf("missing )")
+		}
+		return match, err
+	case "&", "|", ")":
+		return false, fmt.Errorf("unexpected %s", token)
+	}
+	for _, name := range pp.active {
+		if name == token {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// consume the next token if it is the one given
+func (pp *profileParser) next(token string) bool {
+	if pp.pos < len(pp.tokens) && pp.tokens[pp.pos] == token {
+		pp.pos++
+		return true
+	}
+	return false
+}
+
+// split a comma separated list, dropping blank entries
+func splitList(s string) []string {
+	return cleanList(strings.Split(s, ","))
+}
+
+// trim white space from a list of names, dropping blank entries. never returns nil
+func cleanList(names []string) []string {
+	cleaned := []string{}
+	for _, name := range names {
+		name = strings.Trim(name, " \t")
+		if name != "" {
+			cleaned = append(cleaned, name)
+		}
+	}
+	return cleaned
+}
@@ -0,0 +1,230 @@
+package simpleProperties
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestActiveProfiles(t *testing.T) {
+	tests := []struct {
+		name     string
+		setup    func(p *Properties)
+		args     []string
+		env      string
+		property map[string]string
+		want     []string
+	}{
+		{"default profile",
+			func(p *Properties) {},
+			nil, "", nil,
+			[]string{"default"},
+		},
+		{"no default profile",
+			func(p *Properties) { p.SetDefaultProfile() },
+			nil, "", nil,
+			[]string{},
+		},
+		{"changed default profile",
+			func(p *Properties) { p.SetDefaultProfile("local", " dev ") },
+			nil, "", nil,
+			[]string{"local", "dev"},
+		},
+		{"profile property",
+			func(p *Properties) {},
+			nil, "", map[string]string{"profile": "a, b"},
+			[]string{"a", "b"},
+		},
+		{"profile key",
+			func(p *Properties) { p.SetProfileKey("spring.profiles.active") },
+			nil, "", map[string]string{"profile": "a", "spring.profiles.active": "b,c"},
+			[]string{"b", "c"},
+		},
+		{"environment beats property",
+			func(p *Properties) {},
+			nil, "env1,env2", map[string]string{"profile": "a"},
+			[]string{"env1", "env2"},
+		},
+		{"CLI beats environment",
+			func(p *Properties) {},
+			[]string{"app", "-profile=cli", "-other=x"}, "env", map[string]string{"profile": "a"},
+			[]string{"cli"},
+		},
+		{"CLI uses profile key",
+			func(p *Properties) { p.SetProfileKey("active") },
+			[]string{"app", "-profile=cli", "-active=x,y"}, "", nil,
+			[]string{"x", "y"},
+		},
+		{"SetProfiles beats everything",
+			func(p *Properties) { p.SetProfiles("set") },
+			[]string{"app", "-profile=cli"}, "env", map[string]string{"profile": "a"},
+			[]string{"set"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := os.Args
+			defer func() { os.Args = args }()
+			if tt.args != nil {
+				os.Args = tt.args
+			} else {
+				os.Args = []string{"app"}
+			}
+			t.Setenv(profileEnvironment, tt.env)
+			p := EmptyProperties()
+			for k, v := range tt.property {
+				setKV(p, k, v)
+			}
+			tt.setup(p)
+			if got := p.ActiveProfiles(); len(got) != 0 {
+				t.Errorf("ActiveProfiles() before load = %v, want none", got)
+			}
+			ProfilePropertyLoader("testdata/resources/no_such_file")(p)
+			if got := p.ActiveProfiles(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ActiveProfiles() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultProfileLoaded(t *testing.T) {
+	t.Setenv(profileEnvironment, "")
+	p := EmptyProperties()
+	ProfilePropertyLoader("testdata/resources/application")(p)
+	if got := p.GetProperty("default.property"); got != "default profile value" {
+		t.Errorf("GetProperty(default.property) = %v, want %v", got, "default profile value")
+	}
+}
+
+func TestProfileGroupsAndIncludes(t *testing.T) {
+	t.Setenv(profileEnvironment, "")
+	p := EmptyProperties()
+	GlobalPropertyLoader("testdata/resources/groups")(p)
+	if got := p.GetProperty("conditional"); got != "" {
+		t.Errorf("before profiles, conditional = %v, want it held back", got)
+	}
+	ProfilePropertyLoader("testdata/resources/groups")(p)
+	want := []string{"common", "logging", "prod", "prod-db", "metrics"}
+	if got := p.ActiveProfiles(); !reflect.DeepEqual(got, want) {
+		t.Errorf("ActiveProfiles() = %v, want %v", got, want)
+	}
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"level", "prod"},
+		{"common", "yes"},
+		{"logging", "yes"},
+		{"db", "prod"},
+		{"metrics", "on"},
+		{"metrics.region", "global"},
+		{"conditional", "not local"},
+		{"on-profile", ""},
+	}
+	for _, tt := range tests {
+		if got := p.GetProperty(tt.key); got != tt.want {
+			t.Errorf("GetProperty(%v) = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestProfileCycles(t *testing.T) {
+	cycle := []string{"Profile a includes itself through a group or include, ignored"}
+	tests := []struct {
+		name   string
+		values map[string]string
+		want   []string
+		logged []string
+	}{
+		{"group", map[string]string{"profile.group.a": "b", "profile.group.b": "a"}, []string{"a", "b"}, cycle},
+		{"itself", map[string]string{"profile.group.a": "a, b"}, []string{"a", "b"}, cycle},
+		{"shared member", map[string]string{"profile.group.a": "c", "profile.group.b": "c"}, []string{"a", "c", "b"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := &recordingLogger{}
+			p := EmptyProperties()
+			p.SetLogger(logger)
+			for k, v := range tt.values {
+				setKV(p, k, v)
+			}
+			if got := expandProfiles(p, "testdata/resources/nothing/application", []string{"a", "b"}); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expandProfiles() = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(logger.messages, tt.logged) {
+				t.Errorf("logged %v, want %v", logger.messages, tt.logged)
+			}
+		})
+	}
+}
+
+func Test_matchProfiles(t *testing.T) {
+	active := []string{"prod", "eu"}
+	tests := []struct {
+		expression string
+		want       bool
+		wantErr    bool
+	}{
+		{"prod", true, false},
+		{"dev", false, false},
+		{"dev, eu", true, false},
+		{"!local", true, false},
+		{"!prod", false, false},
+		{"prod & eu", true, false},
+		{"prod & !eu", false, false},
+		{"dev | eu", true, false},
+		{"dev | prod & !eu", false, false},
+		{"(dev | prod) & eu", true, false},
+		{"!(prod & eu)", false, false},
+		{"dev,prod&!eu", false, false},
+		{"prod &", false, true},
+		{"(prod", false, true},
+		{"prod)", false, true},
+		{"& prod", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			got, err := matchProfiles(tt.expression, active)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("matchProfiles() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("matchProfiles() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInvalidProfileExpression(t *testing.T) {
+	t.Setenv(profileEnvironment, "")
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "application.properties"), []byte("on-profile=(dev\nlevel=dev\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "application.yaml"), []byte("level: base\n---\non-profile: dev |\nlevel: dev\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	p := EmptyProperties()
+	p.SetProfiles("dev")
+	p.operations = []func(*Properties){GlobalPropertyLoader(filepath.Join(dir, "application")),
+		ProfilePropertyLoader(filepath.Join(dir, "application"))}
+	err := p.Load()
+	var loadError *LoadError
+	if !errors.As(err, &loadError) || len(loadError.Errors) != 2 {
+		t.Fatalf("Load() error = %v, want an error for each expression", err)
+	}
+	for i, want := range []string{
+		filepath.Join(dir, "application.yaml") + ": document at line 3: invalid profile expression dev |",
+		filepath.Join(dir, "application.properties") + ": invalid profile expression (dev",
+	} {
+		if e := loadError.Errors[i]; !errors.Is(e, errProfileExpression) || !strings.HasPrefix(e.Error(), want) {
+			t.Errorf("error %d = %v, want %s", i, e, want)
+		}
+	}
+	if got := p.GetProperty("level"); got != "base" {
+		t.Errorf("GetProperty(level) = %v, want an invalid expression to match nothing", got)
+	}
+}
@@ -2,112 +2,172 @@ package simpleProperties
 
 import (
 	"container/list"
+	"fmt"
 	"strings"
 )
 
 // BasicEvaluator Try to evaluate all properties
-// Step 1 - Update all properties using know values but ignoring defaults
-// Step 2 - Once no more can be evaluated, select one default, and repeat step 1
 //
-//	2a - When selecting a default, check for properties that don't appear in the lhs of any expression
-//	2b - If no default is available for (2a), use the first one that is available
+// Each property holding expressions is resolved once, after the properties it refers to, by walking the graph of
+// references depth first. Properties are visited in sorted order so the result never depends on map order.
 //
-// Step 3 - Once no more evaluations can be made, fail if unevaluated properties still exist, else all good
+//  1. A reference to a property with a value is replaced by that value, even if the value is empty
+//  2. Otherwise, a reference with a default (${prop:name:default}) is replaced by the default
+//  3. Otherwise the reference is left in place, and the property stays unresolved
+//
+// A reference whose name is a namespace, such as ${env:HOME}, is looked up by the namespace's Resolver instead, and
+// doesn't refer to a property. Any other ${a:b} is an unknown namespace, or with lenient namespaces the property a
+// with the default b. See RegisterResolver and SetLenientNamespaces
+//
+// Operator expressions, such as ${a ? b : c}, are evaluated once the properties they name have been resolved, and
+// always give a value. One that could not be parsed is left in place and reported as an error by Load
+//
+// A property has no value when no loader set it, or when its own expressions cannot be fully resolved. Each
+// reference is treated on its own, so ${prop:x:a} and ${prop:x:b} give a and b when x has no value, and the results are
+// the same whatever order the properties were loaded in
+//
+// A property that refers back to itself, directly or through others, is reported as an error by Load. References
+// that close such a cycle are treated as having no value, so a default can still be used for them
 func BasicEvaluator() func(*Properties) {
-	return func(p *Properties) {
-		for true {
-			var changed bool
-			unresolved := p.GetEvalKeys()
-			for _, lhsName := range unresolved {
-				itemsList := p.evalExprMap[lhsName]
-				var next *list.Element
-				for element := itemsList.Front(); element != nil; element = next {
-					next = element.Next()
-					item := element.Value.(*exprParts)
-					// do we have an existing property value for this ?
-					value := p.GetProperty(item.name)
-					changed = changed || doEvaluation(p, value, itemsList, element, lhsName, item, false)
-				}
-			}
-			// start looking at defaults
-			if !changed {
-				unresolved = p.GetEvalKeys()
-				for evalCheck := 0; evalCheck < 2; evalCheck++ { // 0 == check for lhs evaluator, 1 = don't check, use whatever is available
-					if changed {
-						break
-					}
-					// look at all unresolved items
-					for _, lhsName := range unresolved {
-						if changed {
-							break
-						}
-						// do we have a default property value for this ?
-						itemsList := p.evalExprMap[lhsName]
-						// go through all unresolved rhs items until we run out of elements
-						var next *list.Element
-						for element := itemsList.Front(); element != nil; element = next {
-							next = element.Next()
-							item := element.Value.(*exprParts)
-							name := item.name
-							if evalCheck == 0 && hasPotentialEvaluator(p, name) { // may yet get evaluated. Ignore until other defaults expended
-								continue
-							}
-							defaultValue := item.defaultValue
-							changed = doEvaluation(p, defaultValue, itemsList, element, lhsName, item, true)
-							if changed {
-								break
-							}
-						}
-					}
-				}
-			}
-			if !changed {
-				break
+	return evaluatorOperation(DefaultEvaluator())
+}
+
+// the default evaluator, described for BasicEvaluator
+func resolveReferences(c EvaluationContext) {
+	keys := c.Unresolved()
+	r := &resolver{c: c, state: make(map[string]int), items: make(map[string]*list.List, len(keys))}
+	for _, key := range keys {
+		r.items[key] = extractExpressions(c.Raw(key))
+	}
+	for _, key := range keys {
+		r.resolve(key, nil)
+	}
+}
+
+const (
+	unvisited = iota
+	visiting
+	visited
+)
+
+// state for resolving the properties holding expressions
+type resolver struct {
+	c     EvaluationContext
+	state map[string]int
+	items map[string]*list.List // the expressions in each unresolved property
+}
+
+// resolve a property once all the properties it refers to have been resolved. path holds the properties
+// being resolved that led here, for reporting cycles
+func (r *resolver) resolve(key string, path []string) {
+	switch r.state[key] {
+	case visited:
+		return
+	case visiting:
+		for i, name := range path {
+			if name == key {
+				cycle := append(append([]string{}, path[i:]...), key)
+				r.c.Fail("", fmt.Errorf("property cycle %s", strings.Join(cycle, " -> ")))
 			}
 		}
+		return
 	}
+	itemsList, found := r.items[key]
+	if !found {
+		return
+	}
+	r.state[key] = visiting
+	path = append(path, key)
+	for element := itemsList.Front(); element != nil; element = element.Next() {
+		for _, name := range r.references(element.Value.(*exprParts)) {
+			r.resolve(name, path)
+		}
+	}
+	r.substitute(key, itemsList)
+	r.state[key] = visited
 }
 
-// does the named value have a potential evaluator, e.g. abc = ${something} ?
-// if so, this should be used in default value assignment only after all named values without potential assignments
-// have been used first
-func hasPotentialEvaluator(p *Properties, name string) bool {
-	evalKeys := p.GetEvalKeys()
-	for _, key := range evalKeys {
-		if key == name {
-			return true
+// the keys of the properties an expression refers to. a lookup in a namespace other than prop, such as
+// ${env:HOME}, doesn't refer to a property, even one named after the namespace
+func (r *resolver) references(item *exprParts) []string {
+	if item.namespaced() && item.name != propNamespace {
+		if namespace, _ := r.c.Namespace(item.name); namespace != nil {
+			return nil
 		}
 	}
-	return false
+	keys := []string{}
+	for _, name := range item.names() {
+		keys = append(keys, r.c.Key(name))
+	}
+	return keys
 }
 
-// if we have resolved something -->
-// a) remove it from the list of things to resolve
-// b) replace all the placeholders in the rhs with the resolved value
-// c) if the lhs is fully resolved, put it into the remove it from the to be evaluated map and put it into the kv map
-func doEvaluation(p *Properties, resolvedValue string, itemsList *list.List, element *list.Element, lhsName string, item *exprParts, defaultReplacement bool) bool {
-	if resolvedValue != "" {
-		// update rhs expression
-		itemsList.Remove(element)
-		rhs := p.evalKeyValueMap[lhsName]
-		toBeReplaced := item.full
-		var replaceQuantity int
-		if defaultReplacement {
-			replaceQuantity = 1 // so we don't replace all with same default value
-		} else {
-			replaceQuantity = -1 // its not a default value, i.e. resolved lhs so safe to replace all
+// the value of a property, if it has one. a property that closes a cycle has no value
+func (r *resolver) lookup(name string) (string, bool) {
+	if r.state[r.c.Key(name)] == visiting {
+		return "", false
+	}
+	return r.c.Lookup(name)
+}
+
+// the value of ${namespace:key}, or ${name:default} when name is not a namespace. a namespaced reference may
+// also have a default, ${namespace:key:default}
+func (r *resolver) namespaced(key string, item *exprParts) (string, bool) {
+	namespace, err := r.c.Namespace(item.name)
+	if err != nil {
+		r.c.Fail(key, fmt.Errorf("expression %s: %w", item.full, err))
+		return "", false
+	}
+	value, found, defaultValue := "", false, item.defaultValue
+	if namespace == nil {
+		value, found = r.lookup(item.name)
+	} else {
+		var nsKey string
+		nsKey, defaultValue = namespaceKey(item.defaultValue)
+		value, found = namespace.Resolve(nsKey)
+	}
+	if !found && defaultValue != "" {
+		return defaultValue, true
+	}
+	return value, found
+}
+
+// replace each reference in a property with its value or default. each occurrence of a reference is replaced on
+// its own, so ${prop:x:a} and ${prop:x:b} in the same property each get their own default. references that can't be
+// replaced are left in place, and the property stays unresolved
+func (r *resolver) substitute(key string, itemsList *list.List) {
+	rhs := r.c.Raw(key)
+	evaluated := strings.Builder{}
+	position := 0
+	for element := itemsList.Front(); element != nil; element = element.Next() {
+		item := element.Value.(*exprParts)
+		offset := strings.Index(rhs[position:], item.full)
+		if offset < 0 {
+			continue // can't happen, the parts come from this text
+		}
+		evaluated.WriteString(rhs[position : position+offset])
+		position += offset + len(item.full)
+		value, found := "", false
+		switch {
+		case item.err != nil:
+			r.c.Fail(key, item.err)
+		case item.expr != nil:
+			if v, err := item.expr.eval(r.lookup); err != nil {
+				r.c.Fail(key, fmt.Errorf("expression %s: %w", item.full, err))
+			} else {
+				value, found = v.text, true
+			}
+		case item.namespaced():
+			value, found = r.namespaced(key, item)
+		default:
+			value, found = r.lookup(item.name)
 		}
-		evaluatedRhs := strings.Replace(rhs, toBeReplaced, resolvedValue, replaceQuantity)
-		if containsExpression(evaluatedRhs) {
-			// not fully evaluated so just update partially resolved expression
-			p.evalKeyValueMap[lhsName] = evaluatedRhs
+		if found {
+			evaluated.WriteString(value)
 		} else {
-			// finished so remove from expr valuation data and move to resolved properties
-			delete(p.evalKeyValueMap, lhsName)
-			delete(p.evalExprMap, lhsName)
-			p.keyValueMap[lhsName] = evaluatedRhs
-			return true
+			evaluated.WriteString(item.full)
 		}
 	}
-	return false
+	evaluated.WriteString(rhs[position:])
+	r.c.Resolve(key, evaluated.String())
 }
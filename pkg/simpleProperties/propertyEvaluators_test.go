@@ -2,6 +2,10 @@ package simpleProperties
 
 import (
 	"container/list"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -11,11 +15,11 @@ func TestBasicEvaluator(t *testing.T) {
 
 		var baseProps = make(map[string]string)
 		baseProps["profile"] = "evaluator_profile"
-		p := &Properties{make(map[string]string, 32),
-			baseProps,
-			make(map[string]string, 32),
-			make(map[string]*list.List, 32),
-			nil,
+		p := &Properties{
+			bootKeyValueMap: make(map[string]string, 32),
+			keyValueMap:     baseProps,
+			evalKeyValueMap: make(map[string]string, 32),
+			evalExprMap:     make(map[string]*list.List, 32),
 		}
 		// load & evaluate
 		loader := ProfilePropertyLoader("testdata/resources/application")
@@ -30,3 +34,304 @@ func TestBasicEvaluator(t *testing.T) {
 		}
 	})
 }
+
+func TestBasicEvaluatorChains(t *testing.T) {
+	tests := []struct {
+		name   string
+		values map[string]string
+		want   map[string]string
+		errors int
+	}{
+		{"chain",
+			map[string]string{"a": "${b}-a", "b": "${c}-b", "c": "${d}-c", "d": "d"},
+			map[string]string{"a": "d-c-b-a", "b": "d-c-b", "c": "d-c", "d": "d"},
+			0,
+		},
+		{"default for undefined",
+			map[string]string{"a": "${prop:b:x} ${prop:b:y}", "c": "${b}"},
+			map[string]string{"a": "x y", "c": ""},
+			0,
+		},
+		{"empty value is not replaced by default",
+			map[string]string{"a": "[${prop:b:x}]", "b": "", "c": "[${b}]"},
+			map[string]string{"a": "[]", "b": "", "c": "[]"},
+			0,
+		},
+		{"each occurrence uses its own default",
+			map[string]string{"a": "${prop:x:a}", "b": "${prop:x:b}", "c": "${prop:x:c}.${prop:x:a}.${x}"},
+			map[string]string{"a": "a", "b": "b", "c": ""},
+			0,
+		},
+		{"default for unresolvable",
+			map[string]string{"a": "${prop:b:x}", "b": "${c}"},
+			map[string]string{"a": "x", "b": ""},
+			0,
+		},
+		{"partially resolved",
+			map[string]string{"a": "${b} and ${c}", "b": "B"},
+			map[string]string{"a": "", "b": "B"},
+			0,
+		},
+		{"cycle",
+			map[string]string{"a": "${b}", "b": "${c}", "c": "${a}", "d": "${prop:a:none}"},
+			map[string]string{"a": "", "b": "", "c": "", "d": "none"},
+			1,
+		},
+		{"self reference",
+			map[string]string{"a": "${prop:a:x}"},
+			map[string]string{"a": "x"},
+			1,
+		},
+		{"cycle with defaults",
+			map[string]string{"a": "${prop:b:x}", "b": "${prop:a:y}"},
+			map[string]string{"a": "y", "b": "y"},
+			1,
+		},
+		{"namespace lookup is not a reference",
+			map[string]string{"env": "${x}", "x": "${env:SIMPLE_PROPERTIES_NOT_SET:none}"},
+			map[string]string{"env": "none", "x": "none"},
+			0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := EmptyProperties()
+			p.Merge(tt.values)
+			BasicEvaluator()(p)
+			got := make(map[string]string)
+			for k := range tt.values {
+				got[k] = p.GetProperty(k)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BasicEvaluator() = %v, want %v", got, tt.want)
+			}
+			if len(p.errs) != tt.errors {
+				t.Errorf("BasicEvaluator() errors = %v, want %v", p.errs, tt.errors)
+			}
+		})
+	}
+}
+
+func TestBasicEvaluatorPartial(t *testing.T) {
+	p := EmptyProperties()
+	p.Merge(map[string]string{"a": "${b} and ${c}", "b": "B"})
+	BasicEvaluator()(p)
+	if got := p.GetEvalProperty("a"); got != "B and ${c}" {
+		t.Errorf("GetEvalProperty(a) = %v, want %v", got, "B and ${c}")
+	}
+	if got := p.GetExprProperty("a").Len(); got != 1 {
+		t.Errorf("GetExprProperty(a) has %v parts, want 1", got)
+	}
+}
+
+// a random configuration mixing plain, empty, undefined and cyclic references, with different defaults for the
+// same names
+func randomProperties(r *rand.Rand, n int) [][2]string {
+	values := make([][2]string, 0, n)
+	for i := 0; i < n; i++ {
+		var v string
+		switch r.Intn(5) {
+		case 0:
+			v = fmt.Sprintf("value%d", i)
+		case 1:
+			v = ""
+		default:
+			for j := r.Intn(3) + 1; j > 0; j-- {
+				name := fmt.Sprintf("key%d", r.Intn(n+n/2)) // some names are never defined
+				if r.Intn(2) == 0 {
+					v += fmt.Sprintf("<${%s}>", name)
+				} else {
+					v += fmt.Sprintf("<${prop:%s:d%d}>", name, r.Intn(3))
+				}
+			}
+		}
+		values = append(values, [2]string{fmt.Sprintf("key%d", i), v})
+	}
+	return values
+}
+
+// evaluate properties set in the given order, returning every value and the errors found
+func evaluateInOrder(values [][2]string) (map[string]string, []string) {
+	p := EmptyProperties()
+	for _, kv := range values {
+		p.Set(kv[0], kv[1])
+	}
+	BasicEvaluator()(p)
+	got := make(map[string]string)
+	for _, kv := range values {
+		got[kv[0]] = p.GetProperty(kv[0]) + "|" + p.GetEvalProperty(kv[0])
+	}
+	errs := []string{}
+	for _, err := range p.errs {
+		errs = append(errs, err.Error())
+	}
+	return got, errs
+}
+
+func TestBasicEvaluatorOrderIndependent(t *testing.T) {
+	for seed := int64(1); seed <= 50; seed++ {
+		r := rand.New(rand.NewSource(seed))
+		values := randomProperties(r, 5+r.Intn(40))
+		want, wantErrs := evaluateInOrder(values)
+		for run := 0; run < 20; run++ {
+			r.Shuffle(len(values), func(i, j int) { values[i], values[j] = values[j], values[i] })
+			got, gotErrs := evaluateInOrder(values)
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("seed %d: BasicEvaluator() = %v, want %v", seed, got, want)
+			}
+			if !reflect.DeepEqual(gotErrs, wantErrs) {
+				t.Fatalf("seed %d: BasicEvaluator() errors = %v, want %v", seed, gotErrs, wantErrs)
+			}
+		}
+	}
+}
+
+// a generated configuration of n properties, each referring to an earlier one, to a plain value and to an
+// undefined property with a default. the references form a tree, so values only grow with its depth
+func benchmarkProperties(n int) *Properties {
+	p := EmptyProperties()
+	for i := 0; i < 50; i++ {
+		p.Set(fmt.Sprintf("plain%d", i), fmt.Sprintf("value%d", i))
+	}
+	p.Set("key0", "root")
+	for i := 1; i < n; i++ {
+		p.Set(fmt.Sprintf("key%d", i), fmt.Sprintf("${key%d}.${plain%d}.${prop:missing%d:d}", i/2, i%50, i))
+	}
+	return p
+}
+
+func benchmarkEvaluator(b *testing.B, evaluator func(*Properties), n int) {
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		p := benchmarkProperties(n)
+		b.StartTimer()
+		evaluator(p)
+		if len(p.evalKeyValueMap) != 0 {
+			b.Fatalf("%d properties not resolved", len(p.evalKeyValueMap))
+		}
+	}
+}
+
+func BenchmarkBasicEvaluator(b *testing.B) {
+	for _, n := range []int{10, 100, 1000, 20000} {
+		b.Run(fmt.Sprintf("keys=%d", n), func(b *testing.B) {
+			benchmarkEvaluator(b, BasicEvaluator(), n)
+		})
+	}
+}
+
+func BenchmarkFixpointEvaluator(b *testing.B) {
+	for _, n := range []int{10, 100, 1000} {
+		b.Run(fmt.Sprintf("keys=%d", n), func(b *testing.B) {
+			benchmarkEvaluator(b, fixpointEvaluator(), n)
+		})
+	}
+}
+
+// fixpointEvaluator the evaluator BasicEvaluator replaced, kept to benchmark against. It repeatedly scans every
+// unresolved property, restarting after each single default substitution
+// Step 1 - Update all properties using know values but ignoring defaults
+// Step 2 - Once no more can be evaluated, select one default, and repeat step 1
+//
+//	2a - When selecting a default, check for properties that don't appear in the lhs of any expression
+//	2b - If no default is available for (2a), use the first one that is available
+//
+// Step 3 - Once no more evaluations can be made, fail if unevaluated properties still exist, else all good
+func fixpointEvaluator() func(*Properties) {
+	return func(p *Properties) {
+		for true {
+			var changed bool
+			unresolved := p.GetEvalKeys()
+			for _, lhsName := range unresolved {
+				itemsList := p.evalExprMap[lhsName]
+				var next *list.Element
+				for element := itemsList.Front(); element != nil; element = next {
+					next = element.Next()
+					item := element.Value.(*exprParts)
+					// do we have an existing property value for this ?
+					value := p.GetProperty(item.name)
+					changed = changed || fixpointDoEvaluation(p, value, itemsList, element, lhsName, item, false)
+				}
+			}
+			// start looking at defaults
+			if !changed {
+				unresolved = p.GetEvalKeys()
+				for evalCheck := 0; evalCheck < 2; evalCheck++ { // 0 == check for lhs evaluator, 1 = don't check, use whatever is available
+					if changed {
+						break
+					}
+					// look at all unresolved items
+					for _, lhsName := range unresolved {
+						if changed {
+							break
+						}
+						// do we have a default property value for this ?
+						itemsList := p.evalExprMap[lhsName]
+						// go through all unresolved rhs items until we run out of elements
+						var next *list.Element
+						for element := itemsList.Front(); element != nil; element = next {
+							next = element.Next()
+							item := element.Value.(*exprParts)
+							name := item.name
+							if evalCheck == 0 && fixpointHasPotentialEvaluator(p, name) { // may yet get evaluated. Ignore until other defaults expended
+								continue
+							}
+							defaultValue := item.defaultValue
+							changed = fixpointDoEvaluation(p, defaultValue, itemsList, element, lhsName, item, true)
+							if changed {
+								break
+							}
+						}
+					}
+				}
+			}
+			if !changed {
+				break
+			}
+		}
+	}
+}
+
+// fixpointHasPotentialEvaluator does the named value have a potential evaluator, e.g. abc = ${something} ?
+// if so, this should be used in default value assignment only after all named values without potential assignments
+// have been used first
+func fixpointHasPotentialEvaluator(p *Properties, name string) bool {
+	evalKeys := p.GetEvalKeys()
+	for _, key := range evalKeys {
+		if key == name {
+			return true
+		}
+	}
+	return false
+}
+
+// fixpointDoEvaluation if we have resolved something -->
+// a) remove it from the list of things to resolve
+// b) replace all the placeholders in the rhs with the resolved value
+// c) if the lhs is fully resolved, put it into the remove it from the to be evaluated map and put it into the kv map
+func fixpointDoEvaluation(p *Properties, resolvedValue string, itemsList *list.List, element *list.Element, lhsName string, item *exprParts, defaultReplacement bool) bool {
+	if resolvedValue != "" {
+		// update rhs expression
+		itemsList.Remove(element)
+		rhs := p.evalKeyValueMap[lhsName]
+		toBeReplaced := item.full
+		var replaceQuantity int
+		if defaultReplacement {
+			replaceQuantity = 1 // so we don't replace all with same default value
+		} else {
+			replaceQuantity = -1 // its not a default value, i.e. resolved lhs so safe to replace all
+		}
+		evaluatedRhs := strings.Replace(rhs, toBeReplaced, resolvedValue, replaceQuantity)
+		if containsExpression(evaluatedRhs) {
+			// not fully evaluated so just update partially resolved expression
+			p.evalKeyValueMap[lhsName] = evaluatedRhs
+		} else {
+			// finished so remove from expr valuation data and move to resolved properties
+			delete(p.evalKeyValueMap, lhsName)
+			delete(p.evalExprMap, lhsName)
+			p.keyValueMap[lhsName] = evaluatedRhs
+			return true
+		}
+	}
+	return false
+}
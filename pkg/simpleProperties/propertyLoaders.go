@@ -3,6 +3,7 @@ package simpleProperties
 import (
 	"bufio"
 	"container/list"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"gopkg.in/yaml.v3"
@@ -11,21 +12,24 @@ import (
 	"log"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strconv"
 	"strings"
+	"time"
 )
 
 const basePath = "resources/application"
 const bootstrapPath = "resources/bootstrap"
-
-var expression_matcher, _ = regexp.Compile("(\\$+\\{\\S+(:\\S+){0,1}})") // find all ${} expressions
-var name_matcher, _ = regexp.Compile("\\$\\{(\\S+?){1}(:\\S+?){0,1}}")   // find name & default values ${abc:xyz) -> abc, :xyz
+const profileActivationKey = "on-profile" // yaml documents with this key only load for the listed profiles
+const commandLineSource = "command line"  // the source recorded for properties from CLI parameters
+const environmentSource = "environment"   // the source recorded for properties from the O/S environment
 
 // BootPropertyLoader load properties from the boostrap file(s)
 func BootPropertyLoader(path string) func(*Properties) {
 	return func(p *Properties) {
+		raw, sources := p.rawKeyValueMap, p.sources
 		baseLoader(p, path)
+		p.rawKeyValueMap, p.sources = raw, sources // boot properties are kept apart from the application properties
+		p.pending = nil                            // no profile is ever active for bootstrap files, so conditional content never applies
 		tempMap := p.bootKeyValueMap
 		p.bootKeyValueMap = p.keyValueMap
 		p.keyValueMap = tempMap
@@ -39,17 +43,38 @@ func GlobalPropertyLoader(path string) func(*Properties) {
 	}
 }
 
-// ProfilePropertyLoader load properties from the application_<profile> property file(s)
+// FileLoader load properties from a single file, in the format given by its extension, along with any files it
+// imports. a file that can't be read, or whose format isn't known, is reported by Load
+func FileLoader(path string) func(*Properties) {
+	return func(p *Properties) {
+		loader := formatLoader(filepath.Ext(path))
+		if loader == nil {
+			p.errs = append(p.errs, fmt.Errorf("%s: unknown file format %s", path, filepath.Ext(path)))
+			return
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			p.errs = append(p.errs, err)
+			return
+		}
+		loadFile(p, loader, file, path, nil)
+	}
+}
+
+// ProfilePropertyLoader load properties from the application_<profile> property file(s). the active profiles are
+// chosen as described for ActiveProfiles, expanded by any profile groups and includes, and loaded in order, so a
+// later profile overrides an earlier one. any content held back for an on-profile key is applied first, so
+// profile files still take precedence over it
 func ProfilePropertyLoader(path string) func(*Properties) {
 	return func(p *Properties) {
-		profileNames := strings.Split(p.keyValueMap["profile"], ",")
-		if len(profileNames) > 0 {
-			for _, profileName := range profileNames {
-				if profileName != "" {
-					name := strings.Trim(profileName, " \t")
-					baseLoader(p, path+"_"+name)
-				}
-			}
+		p.profiles = expandProfiles(p, path, selectProfiles(p))
+		pending := p.pending
+		p.pending = nil
+		for _, load := range pending {
+			load(p)
+		}
+		for _, name := range p.profiles {
+			baseLoader(p, path+"_"+name)
 		}
 	}
 }
@@ -62,30 +87,25 @@ func LoadOSEnvironment() func(*Properties) {
 			if len(parts) < 2 {
 				log.Fatalf("Invalid environment variable string: %s", kv)
 			}
-			setKV(p, parts[0], parts[1])
+			setKVFrom(p, parts[0], parts[1], environmentSource)
 		}
 	}
 }
 
-// LoadCLIParameters loads -key=value CLI parameters
+// LoadCLIParameters loads -key=value CLI parameters, also given as --key=value, up to any --. A property already
+// loaded from a file, given a default or named in the schema may also be given as -key value, which takes the next
+// argument as its value unless it starts with - and isn't a number. Arguments for flags bound with BindFlags are not
+// read as parameters, but the values of those flags given on the command line are. Each parameter is logged, with
+// the value of one that looks like it holds a secret redacted
 func LoadCLIParameters() func(*Properties) {
 	return func(p *Properties) {
-		if len(os.Args) > 1 { // ignore run param
-			var args = os.Args[1:]
-			for _, argString := range args {
-				arg := []rune(argString)
-				log.Printf("CLI arg %c", arg)
-				if len(arg) >= 3 { // smallest is -k=
-					if arg[0] == '-' {
-						arg = arg[1:]
-						before, after, found := strings.Cut(string(arg), "=")
-						if found && len(before) > 0 { // allow blank values
-							log.Printf("CLI key %s = %s", before, after)
-							setKV(p, before, after)
-						}
-					}
-				}
+		for _, kv := range p.cliParameters(os.Args) {
+			value := kv[1]
+			if sensitiveKey(kv[0]) {
+				value = redacted
 			}
+			p.logf("CLI key %s = %s", kv[0], value)
+			setKVFrom(p, kv[0], kv[1], commandLineSource)
 		}
 	}
 }
@@ -94,67 +114,281 @@ func LoadCLIParameters() func(*Properties) {
 // utilities
 //
 
-// load properties from the file specified in the path.  Look for .yaml, .json and .properties files with the
-// load order being .yaml least to .properties highest
+// load properties from the file specified in the path.  Look for a file with each of the extensions in the
+// format order of the properties, by default .yaml, .json and .properties files with the load order being
+// .yaml least to .properties highest
 func baseLoader(p *Properties, path string) {
 	dir, filename := filepath.Split(path)
 	fsys := os.DirFS(dir)
-	file, err := fsys.Open(filename + ".yaml")
-	if err == nil {
-		loadYAML(p, file)
+	for _, extension := range p.formatOrder() {
+		file, err := fsys.Open(filename + extension)
+		if err == nil {
+			loadFile(p, formatLoader(extension), file, path+extension, nil)
+		}
+	}
+}
+
+// load a single property file. the file is read into a scratch properties structure first so that it can be
+// treated as a unit. if it holds an on-profile key, it only applies when that profile expression matches the
+// active profiles, and until these are known it is held back for the profile loader. any files it imports are
+// loaded straight after it, see loadImports. chain lists the files importing this one
+func loadFile(p *Properties, loader func(*Properties, fs.File), file fs.File, path string, chain []string) {
+	scratch := scratchProperties(p)
+	loader(scratch, file)
+	p.errs = append(p.errs, fileErrors(path, scratch.errs)...)
+	for _, held := range scratch.pending {
+		p.pending = append(p.pending, heldDocument(held, path, chain))
+	}
+	imports := takeImports(scratch)
+	activation, conditional := scratch.keyValueMap[profileActivationKey]
+	delete(scratch.keyValueMap, profileActivationKey)
+	load := func(p *Properties) {
+		if !conditional || profileActive(p, path, activation) {
+			mergeProperties(p, scratch, path)
+			loadImports(p, path, imports, append(append([]string{}, chain...), path))
+		}
 	}
-	file, err = fsys.Open(filename + ".json")
-	if err == nil {
-		loadJSON(p, file)
+	if conditional && p.profiles == nil {
+		p.pending = append(p.pending, load)
+	} else {
+		load(p)
 	}
-	file, err = fsys.Open(filename + ".properties")
-	if err == nil {
-		loadPropertiesFromFile(p, file)
+}
+
+// a part of a file held back by its loader until the active profiles are known, such as an on-profile yaml
+// document. when run, it is loaded as loadFile loads a file, into a scratch properties structure that is then
+// merged with the file as the source, followed by any files it imports
+func heldDocument(held func(*Properties), path string, chain []string) func(*Properties) {
+	return func(p *Properties) {
+		scratch := scratchProperties(p)
+		held(scratch)
+		p.errs = append(p.errs, fileErrors(path, scratch.errs)...)
+		imports := takeImports(scratch)
+		mergeProperties(p, scratch, path)
+		loadImports(p, path, imports, append(append([]string{}, chain...), path))
+	}
+}
+
+// an empty properties structure to load a file into, sharing the active profiles of p
+func scratchProperties(p *Properties) *Properties {
+	return &Properties{
+		keyValueMap:     make(map[string]string),
+		evalKeyValueMap: make(map[string]string),
+		evalExprMap:     make(map[string]*list.List),
+		profiles:        p.profiles,
+	}
+}
+
+// the errors found loading a file, naming the file
+func fileErrors(path string, errs []error) []error {
+	named := make([]error, 0, len(errs))
+	for _, err := range errs {
+		named = append(named, fmt.Errorf("%s: %w", path, err))
 	}
+	return named
 }
 
-// load properties from the specified .properties file
+// remove the import keys from the properties loaded into a scratch properties structure, returning the files listed
+func takeImports(scratch *Properties) []string {
+	imports := []string{}
+	for _, key := range importKeys {
+		imports = append(imports, splitList(scratch.keyValueMap[key])...)
+		delete(scratch.keyValueMap, key)
+	}
+	return imports
+}
+
+// copy the properties loaded into a scratch properties structure, recording the source of each one. a source
+// recorded in the scratch structure, such as the file holding a config tree key, is kept
+func mergeProperties(p *Properties, from *Properties, source string) {
+	merge := func(k string, v string) {
+		if s, found := from.sources[k]; found {
+			setKVFrom(p, k, v, s)
+		} else {
+			setKVFrom(p, k, v, source)
+		}
+	}
+	for k, v := range from.keyValueMap {
+		merge(k, v)
+	}
+	for k, v := range from.evalKeyValueMap {
+		merge(k, v)
+	}
+}
+
+// load properties from the specified .properties file. blank lines and lines starting with # or ! are skipped.
+// the key runs up to the first = that is not escaped, and both key and value may hold the escapes described
+// for unescapeProperty
 func loadPropertiesFromFile(p *Properties, file fs.File) {
 	defer file.Close()
 	scanner := bufio.NewScanner(file)
 	scanner.Split(bufio.ScanLines)
 
 	for scanner.Scan() {
-		line := scanner.Text()
-		strings.Trim(line, " \t")
-		if line == "" {
+		line := strings.TrimLeft(scanner.Text(), " \t")
+		if line == "" || line[0] == '#' || line[0] == '!' {
 			continue
 		}
-		parts := strings.Split(line, "=")
-		if len(parts) < 2 {
+		separator := propertySeparator(line)
+		if separator < 0 {
 			log.Fatalf("Invalid property string: %s", line)
 		}
-		setKV(p, parts[0], parts[1])
+		setKV(p, unescapeProperty(line[:separator]), unescapeProperty(line[separator+1:]))
 	}
 }
 
-// load properties from the specified .json file
+// find the first = in a line that is not escaped with a \
+func propertySeparator(line string) int {
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '=':
+			return i
+		}
+	}
+	return -1
+}
+
+// replace the escapes in a .properties key or value. \n, \r, \t and \f give the control characters, \uXXXX a
+// unicode character, and a \ before any of \ = : # ! or a space gives that character. a \ before anything else
+// is kept as it is, so most windows paths, such as c:\work, need no escaping
+func unescapeProperty(s string) string {
+	if !strings.Contains(s, "\\") {
+		return s
+	}
+	b := strings.Builder{}
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i == len(s)-1 {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case 'f':
+			b.WriteByte('\f')
+		case 'u':
+			if i+4 < len(s) {
+				if r, err := strconv.ParseUint(s[i+1:i+5], 16, 16); err == nil {
+					b.WriteRune(rune(r))
+					i += 4
+					break
+				}
+			}
+			b.WriteString("\\u")
+		case '\\', '=', ':', '#', '!', ' ':
+			b.WriteByte(s[i])
+		default:
+			b.WriteByte('\\')
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// load properties from the specified .json file. numbers are decoded as json.Number so that the text
+// in the file is kept exactly, e.g. 8080.0 stays 8080.0 and large integers are not rounded via float64
 func loadJSON(p *Properties, file fs.File) {
 	defer file.Close()
-	byteValue, _ := io.ReadAll(file)
 	var result map[string]interface{}
-	err := json.Unmarshal([]byte(byteValue), &result)
+	decoder := json.NewDecoder(file)
+	decoder.UseNumber()
+	err := decoder.Decode(&result)
 	if err != nil {
 		log.Fatalf("Invalid properties JSON file. Error %s", err)
 	}
 	extractKVMap(p, result, "")
 }
 
-// load properties from the specified .yaml file
+// load properties from the specified .yaml file. the file is decoded into a node tree rather than a map
+// so that scalars keep their original text whatever type yaml would resolve them to.
+//
+// a file may hold several documents separated by ---. these are layered in order, so later documents
+// override earlier ones. a document with an on-profile key only applies when one of the listed profiles
+// is active, see matchProfiles. until the active profiles are known, such documents are held back and
+// applied by the profile loader. a held back document leaves alone the keys set by the plain documents
+// after it in the file, so these still override it
 func loadYAML(p *Properties, file fs.File) {
 	defer file.Close()
-	byteValue, _ := io.ReadAll(file)
-	result := make(map[string]interface{})
-	err := yaml.Unmarshal(byteValue, &result)
-	if err != nil {
-		log.Fatalf("Invalid properties YAML file. Error %s", err)
+	decoder := yaml.NewDecoder(file)
+	documents := []*yaml.Node{}
+	for {
+		var document yaml.Node
+		err := decoder.Decode(&document)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Fatalf("Invalid properties YAML file. Error %s", err)
+		}
+		if len(document.Content) == 0 {
+			continue // empty document
+		}
+		root := document.Content[0]
+		if root.Kind != yaml.MappingNode {
+			log.Fatalf("Invalid properties YAML file. Error line %d: expected a mapping at the top level", root.Line)
+		}
+		documents = append(documents, root)
+	}
+	for i, root := range documents {
+		if _, found := yamlActivation(root); found && p.profiles == nil {
+			root, later := root, plainDocumentKeys(documents[i+1:])
+			p.pending = append(p.pending, func(p *Properties) { loadYAMLDocument(p, root, later) })
+			continue
+		}
+		loadYAMLDocument(p, root, nil)
 	}
-	extractKVMap(p, result, "")
+}
+
+// load a single yaml document, skipping it if its on-profile expression does not match the active profiles.
+// keys in skip are left as they are
+func loadYAMLDocument(p *Properties, root *yaml.Node, skip map[string]bool) {
+	if activation, found := yamlActivation(root); found &&
+		!profileActive(p, fmt.Sprintf("document at line %d", root.Line), activation) {
+		return
+	}
+	if len(skip) == 0 {
+		extractYAMLNode(p, root, "")
+		return
+	}
+	scratch := EmptyProperties()
+	extractYAMLNode(scratch, root, "")
+	for k, v := range scratch.rawKeyValueMap {
+		if !skip[k] {
+			setKV(p, k, v)
+		}
+	}
+}
+
+// the keys set by the documents with no on-profile key in a list of yaml documents
+func plainDocumentKeys(documents []*yaml.Node) map[string]bool {
+	keys := make(map[string]bool)
+	for _, root := range documents {
+		if _, found := yamlActivation(root); !found {
+			scratch := EmptyProperties()
+			extractYAMLNode(scratch, root, "")
+			for k := range scratch.rawKeyValueMap {
+				keys[k] = true
+			}
+		}
+	}
+	return keys
+}
+
+// find the on-profile value of a yaml document, if it has one
+func yamlActivation(root *yaml.Node) (string, bool) {
+	for _, pair := range yamlPairs(root) {
+		if pair[0].Value == profileActivationKey {
+			return pair[1].Value, true
+		}
+	}
+	return "", false
 }
 
 // recursively work through a map of key -> value, and convert each found value into a string.
@@ -169,22 +403,32 @@ func loadYAML(p *Properties, file fs.File) {
 //	}
 //
 // then the recursive prefix would be level1, giving a full property key of level1.level2
-func extractKVMap(p *Properties, json map[string]interface{}, prefix string) {
+func extractKVMap(p *Properties, kvMap map[string]interface{}, prefix string) {
 	var value string
 	skip := false
-	for key := range json {
-		rawValue := json[key]
+	for key := range kvMap {
+		rawValue := kvMap[key]
 		name := prefix + key
 		if rawValue != nil {
 			switch valueType := rawValue.(type) {
 			case int:
 				value = strconv.Itoa(valueType)
+			case int64:
+				value = strconv.FormatInt(valueType, 10)
+			case uint64:
+				value = strconv.FormatUint(valueType, 10)
 			case string:
 				value = valueType
+			case json.Number:
+				value = valueType.String()
 			case float64:
-				value = fmt.Sprintf("%g", valueType)
+				value = strconv.FormatFloat(valueType, 'f', -1, 64)
 			case bool:
 				value = strconv.FormatBool(valueType)
+			case time.Time:
+				value = valueType.Format(time.RFC3339Nano)
+			case []byte:
+				value = base64.StdEncoding.EncodeToString(valueType)
 			case map[string]interface{}:
 				skip = true
 				extractKVMap(p, valueType, name+".")
@@ -202,48 +446,219 @@ func extractKVMap(p *Properties, json map[string]interface{}, prefix string) {
 	}
 }
 
+// recursively work through a yaml mapping node in the same way as extractKVMap. scalar values are
+// taken as written in the file, so 12345678901234567890, 8080.0, 007 and timestamps are not reformatted.
+// a null value gives an empty string. aliases are followed, and merge keys (<<) are expanded, so an
+// anchored block appears under every key that refers to it
+func extractYAMLNode(p *Properties, node *yaml.Node, prefix string) {
+	for _, pair := range yamlPairs(node) {
+		key := pair[0].Value
+		if prefix == "" && key == profileActivationKey {
+			continue
+		}
+		name := prefix + key
+		valueNode := pair[1]
+		switch valueNode.Kind {
+		case yaml.ScalarNode:
+			if valueNode.ShortTag() == "!!null" {
+				setKV(p, name, "")
+			} else {
+				setKV(p, name, valueNode.Value)
+			}
+		case yaml.MappingNode:
+			extractYAMLNode(p, valueNode, name+".")
+		default:
+			setKV(p, name, "???")
+		}
+	}
+}
+
+// list the key / value node pairs of a mapping with aliases resolved and merge keys expanded. as with
+// yaml itself, keys written in the mapping win over merged keys, and with a list of merged mappings
+// (<<: [*a, *b]) the first mapping to hold a key wins
+func yamlPairs(node *yaml.Node) [][2]*yaml.Node {
+	node = yamlResolve(node)
+	pairs := [][2]*yaml.Node{}
+	if node.Kind != yaml.MappingNode {
+		return pairs
+	}
+	seen := make(map[string]bool)
+	merged := []*yaml.Node{}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode, valueNode := node.Content[i], yamlResolve(node.Content[i+1])
+		if keyNode.ShortTag() == "!!merge" {
+			if valueNode.Kind == yaml.SequenceNode {
+				merged = append(merged, valueNode.Content...)
+			} else {
+				merged = append(merged, valueNode)
+			}
+			continue
+		}
+		seen[keyNode.Value] = true
+		pairs = append(pairs, [2]*yaml.Node{keyNode, valueNode})
+	}
+	for _, m := range merged {
+		for _, pair := range yamlPairs(m) {
+			if !seen[pair[0].Value] {
+				seen[pair[0].Value] = true
+				pairs = append(pairs, pair)
+			}
+		}
+	}
+	return pairs
+}
+
+// follow an alias to the anchored node
+func yamlResolve(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	return node
+}
+
+// find the key value parameters in a list of CLI arguments, given as -key=value, --key=value, -key value or
+// --key value. the first argument is the run param and is ignored, as is everything after --. arguments for bound
+// flags are left to their flag sets, and the flags given on the command line follow the parameters found.
+//
+// the space separated form is only taken for a key the properties already know, see knownKey, so that in
+// app -debug input.txt, input.txt is left as an argument. its value may be a negative number, as in -offset -5,
+// but not anything else starting with -
+func (p *Properties) cliParameters(args []string) [][2]string {
+	parameters := [][2]string{}
+	bound := p.boundFlags()
+	for i := 1; i < len(args); i++ { // ignore run param
+		arg := args[i]
+		if arg == "--" {
+			break
+		}
+		if len(arg) < 2 || arg[0] != '-' {
+			continue
+		}
+		name, value, found := strings.Cut(strings.TrimPrefix(arg[1:], "-"), "=")
+		if name == "" || name[0] == '-' {
+			continue
+		}
+		if f, isFlag := bound[name]; isFlag {
+			if !found && !f.IsBool {
+				i++ // skip its value
+			}
+			continue
+		}
+		if !found { // -key value
+			if !p.knownKey(name) || i+1 >= len(args) || (strings.HasPrefix(args[i+1], "-") && !isNumber(args[i+1])) {
+				continue
+			}
+			i++
+			value = args[i]
+		}
+		parameters = append(parameters, [2]string{name, value}) // allow blank values
+	}
+	for _, s := range p.flagSources {
+		for _, f := range s.Flags() {
+			if f.Set {
+				parameters = append(parameters, [2]string{f.Name, f.Value})
+			}
+		}
+	}
+	return parameters
+}
+
+// whether a name is a property the properties know of before reading the CLI parameters: one loaded, set or given
+// a default, a bootstrap property, one in the schema, or the profile key
+func (p *Properties) knownKey(name string) bool {
+	key := p.storedKey(name)
+	for _, m := range []map[string]string{p.rawKeyValueMap, p.defaultKeyValueMap, p.bootKeyValueMap} {
+		if _, found := m[key]; found {
+			return true
+		}
+	}
+	_, inSchema := p.schema[key]
+	return inSchema || name == p.GetProfileKey()
+}
+
 // put a kev pair into the property map. leading / trailing white space is removed
 func setKV(p *Properties, key string, value string) {
+	setKVFrom(p, key, value, "")
+}
+
+// put a key value pair into the property map as setKV does, recording where it came from. a key that is an alias
+// is stored under the name it stands for, and with relaxed keys, a key is stored in its canonical form
+func setKVFrom(p *Properties, key string, value string, source string) {
 	k := strings.Trim(key, " \t")
 	v := strings.Trim(value, " \t")
-	if k != "" {
-		if containsExpression(value) {
-			// value with evaluation fields
-			p.evalKeyValueMap[k] = v
-			p.evalExprMap[k] = extractExpressions(value)
-
-		} else {
-			// simple value
-			p.keyValueMap[k] = v
+	if k == "" {
+		return
+	}
+	k = p.applyAlias(p.relaxKey(k, source), source)
+	if p.rawKeyValueMap == nil {
+		p.rawKeyValueMap = make(map[string]string)
+	}
+	p.rawKeyValueMap[k] = v
+	if source != "" {
+		if p.sources == nil {
+			p.sources = make(map[string]string)
 		}
+		p.sources[k] = source
+	} else {
+		delete(p.sources, k)
+	}
+	storeKV(p, k, v)
+}
+
+// store a trimmed key / value pair in the map for its kind of value, without recording it as loaded
+func storeKV(p *Properties, k string, v string) {
+	if containsExpression(v) {
+		// value with evaluation fields
+		p.evalKeyValueMap[k] = v
+		p.evalExprMap[k] = extractExpressions(v)
+		delete(p.keyValueMap, k)
+	} else {
+		// simple value
+		p.keyValueMap[k] = v
+		delete(p.evalKeyValueMap, k)
+		delete(p.evalExprMap, k)
 	}
 }
 
 // extract all expression in the rhs property
 func extractExpressions(value string) *list.List {
-	expr := value
-	var parts = expression_matcher.FindAllString(expr, -1)
-	fmt.Println(parts)
 	l := list.New()
-	for _, v := range parts {
-		name := name_matcher.FindStringSubmatch(v) // gives ${} then name, then :default if it exists
-		fmt.Println("name=", name)
-		defaultValue := name[2]
-		if defaultValue != "" {
-			defaultValue = defaultValue[1:]
-		}
-		l.PushBack(&exprParts{name[0], name[1], defaultValue})
+	for _, e := range scanExpressions(value) {
+		l.PushBack(parseExpression(e.full, e.content))
 	}
 	return l
 }
 
 func containsExpression(s string) bool {
-	r := expression_matcher.FindAllString(s, -1)
-	return len(r) > 0
+	return len(scanExpressions(s)) > 0
 }
 
 type exprParts struct {
-	full         string // with  ${abc:xyz}, this is ${abc:xyz}
-	name         string // with  ${abc:xyz}, this is abc
-	defaultValue string // with  ${abc:xyz}, this is xyz
+	full         string   // with  ${abc:xyz}, this is ${abc:xyz}
+	name         string   // with  ${abc:xyz}, this is abc. empty for an operator expression
+	defaultValue string   // with  ${abc:xyz}, this is xyz
+	expr         exprNode // with  ${a ? b : c}, the parsed expression. nil for a plain reference
+	err          error    // why the expression could not be parsed
+}
+
+// whether a plain reference has a namespace or default, as in ${env:HOME} or ${name:default}
+func (e *exprParts) namespaced() bool {
+	return e.expr == nil && strings.HasPrefix(e.full, "${"+e.name+":")
+}
+
+// the property names an expression refers to. for ${prop:key}, this is the key
+func (e *exprParts) names() []string {
+	if e.expr == nil {
+		if e.err != nil {
+			return nil
+		}
+		if e.name == propNamespace && e.namespaced() {
+			key, _ := namespaceKey(e.defaultValue)
+			return []string{key}
+		}
+		return []string{e.name}
+	}
+	names := []string{}
+	e.expr.names(func(name string) { names = append(names, name) })
+	return names
 }
@@ -2,8 +2,10 @@ package simpleProperties
 
 import (
 	"container/list"
+	"encoding/json"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestBootPropertyLoader(t *testing.T) {
@@ -26,21 +28,21 @@ func TestBootPropertyLoader(t *testing.T) {
 			name: "Test bootstrap loader",
 			args: args{"testdata/resources/bootstrap"},
 			want: Properties{
-				bootProperties,
-				make(map[string]string),
-				make(map[string]string),
-				make(map[string]*list.List),
-				nil},
+				bootKeyValueMap: bootProperties,
+				keyValueMap:     make(map[string]string),
+				evalKeyValueMap: make(map[string]string),
+				evalExprMap:     make(map[string]*list.List),
+			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			var testProperties = &Properties{
-				make(map[string]string),
-				make(map[string]string),
-				make(map[string]string),
-				make(map[string]*list.List),
-				nil}
+				bootKeyValueMap: make(map[string]string),
+				keyValueMap:     make(map[string]string),
+				evalKeyValueMap: make(map[string]string),
+				evalExprMap:     make(map[string]*list.List),
+			}
 
 			f := BootPropertyLoader(tt.args.path)
 			f(testProperties)
@@ -68,9 +70,20 @@ func TestGlobalPropertyLoader(t *testing.T) {
 	expressionProperties["expression"] = "An expression ${xyzzy}"
 	//
 	l := list.New()
-	l.PushBack(&exprParts{"${xyzzy}", "xyzzy", ""})
+	l.PushBack(&exprParts{full: "${xyzzy}", name: "xyzzy"})
 	expressionList := make(map[string]*list.List)
 	expressionList["expression"] = l
+	//
+	rawProperties := copyKV(globalProperties)
+	rawProperties["expression"] = "An expression ${xyzzy}"
+	//
+	sources := map[string]string{
+		"expression":  "testdata/resources/application.properties",
+		"json1":       "testdata/resources/application.json",
+		"profile":     "testdata/resources/application.properties",
+		"properties1": "testdata/resources/application.properties",
+		"yaml1":       "testdata/resources/application.yaml",
+	}
 
 	type args struct {
 		path string
@@ -84,11 +97,13 @@ func TestGlobalPropertyLoader(t *testing.T) {
 			name: "Test global property loader",
 			args: args{"testdata/resources/application"},
 			want: Properties{
-				make(map[string]string),
-				globalProperties,
-				expressionProperties,
-				expressionList,
-				nil},
+				bootKeyValueMap: make(map[string]string),
+				keyValueMap:     globalProperties,
+				evalKeyValueMap: expressionProperties,
+				evalExprMap:     expressionList,
+				rawKeyValueMap:  rawProperties,
+				sources:         sources,
+			},
 		},
 		// TODO: Add test cases.
 	}
@@ -104,6 +119,27 @@ func TestGlobalPropertyLoader(t *testing.T) {
 	}
 }
 
+func TestFileLoader(t *testing.T) {
+	p := EmptyProperties()
+	p.operations = []func(*Properties){FileLoader("testdata/resources/formats.yaml")}
+	if err := p.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(p.GetKeys()) == 0 || p.sources[p.GetKeys()[0]] != "testdata/resources/formats.yaml" {
+		t.Errorf("expected the properties of formats.yaml, got %v", p.sources)
+	}
+	for path, want := range map[string]string{
+		"testdata/resources/missing.yaml":    "open testdata/resources/missing.yaml: no such file or directory",
+		"testdata/resources/application.ini": "testdata/resources/application.ini: unknown file format .ini",
+	} {
+		p := EmptyProperties()
+		p.operations = []func(*Properties){FileLoader(path)}
+		if err := p.Load(); err == nil || err.Error() != want {
+			t.Errorf("Load(%s) error = %v, want %s", path, err, want)
+		}
+	}
+}
+
 func TestProfilePropertyLoader(t *testing.T) {
 	profileProperties := make(map[string]string)
 	profileProperties["profile"] = "test_profile"
@@ -121,11 +157,14 @@ func TestProfilePropertyLoader(t *testing.T) {
 			name: "Test profile property loader",
 			args: args{"testdata/resources/application"},
 			want: Properties{
-				make(map[string]string),
-				profileProperties,
-				make(map[string]string),
-				make(map[string]*list.List),
-				nil},
+				bootKeyValueMap: make(map[string]string),
+				keyValueMap:     profileProperties,
+				evalKeyValueMap: make(map[string]string),
+				evalExprMap:     make(map[string]*list.List),
+				rawKeyValueMap:  map[string]string{"profile.property": "property profile value"},
+				sources:         map[string]string{"profile.property": "testdata/resources/application_test_profile.properties"},
+				profiles:        []string{"test_profile"},
+			},
 		},
 		// TODO: Add test cases.
 	}
@@ -133,11 +172,11 @@ func TestProfilePropertyLoader(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			var baseProps = make(map[string]string)
 			baseProps["profile"] = "test_profile"
-			p := &Properties{make(map[string]string, 32),
-				baseProps,
-				make(map[string]string, 32),
-				make(map[string]*list.List, 32),
-				nil,
+			p := &Properties{
+				bootKeyValueMap: make(map[string]string, 32),
+				keyValueMap:     baseProps,
+				evalKeyValueMap: make(map[string]string, 32),
+				evalExprMap:     make(map[string]*list.List, 32),
 			}
 			f := ProfilePropertyLoader(tt.args.path)
 			f(p)
@@ -180,7 +219,7 @@ func Test_extractExpressions(t *testing.T) {
 			args{"hello ${person}"},
 			func() *list.List {
 				l := list.New()
-				l.PushBack(&exprParts{"${person}", "person", ""})
+				l.PushBack(&exprParts{full: "${person}", name: "person"})
 				return l
 			}(),
 		},
@@ -188,7 +227,28 @@ func Test_extractExpressions(t *testing.T) {
 			args{"hello ${person:unknown}"},
 			func() *list.List {
 				l := list.New()
-				l.PushBack(&exprParts{"${person:unknown}", "person", "unknown"})
+				l.PushBack(&exprParts{full: "${person:unknown}", name: "person", defaultValue: "unknown"})
+				return l
+			}(),
+		},
+		{"Expression extract adjacent",
+			args{"${host}:${port:80}"},
+			func() *list.List {
+				l := list.New()
+				l.PushBack(&exprParts{full: "${host}", name: "host"})
+				l.PushBack(&exprParts{full: "${port:80}", name: "port", defaultValue: "80"})
+				return l
+			}(),
+		},
+		{"Expression extract conditional",
+			args{"${on ? a : 'b'}"},
+			func() *list.List {
+				l := list.New()
+				l.PushBack(&exprParts{full: "${on ? a : 'b'}", expr: &conditionalNode{
+					condition: &wordNode{name: "on"},
+					then:      &wordNode{name: "a"},
+					otherwise: &literalNode{text: "b"},
+				}})
 				return l
 			}(),
 		},
@@ -236,3 +296,106 @@ func Test_setKV(t *testing.T) {
 		})
 	}
 }
+
+func TestNumberFormatting(t *testing.T) {
+	tests := []struct {
+		name string
+		key  string
+		want string
+	}{
+		{"json large integer", "json.big", "12345678901234567890"},
+		{"json float with zero fraction", "json.port", "8080.0"},
+		{"json negative", "json.negative", "-42"},
+		{"json exponent", "json.exponent", "1.5e3"},
+		{"json quoted leading zeros", "json.id", "007"},
+		{"json null", "json.nothing", ""},
+		{"yaml large integer", "yaml.big", "12345678901234567890"},
+		{"yaml integer beyond uint64", "yaml.huge", "123456789012345678901234567890"},
+		{"yaml float with zero fraction", "yaml.port", "8080.0"},
+		{"yaml negative", "yaml.negative", "-42"},
+		{"yaml hex", "yaml.hex", "0x1F"},
+		{"yaml leading zeros", "yaml.id", "007"},
+		{"yaml boolean", "yaml.boolean", "True"},
+		{"yaml timestamp", "yaml.timestamp", "2001-12-14t21:59:43.10-05:00"},
+		{"yaml binary", "yaml.binary", "R0lGODlhDAAMAIQAAP"},
+		{"yaml null", "yaml.nothing", ""},
+	}
+	p := EmptyProperties()
+	f := GlobalPropertyLoader("testdata/resources/numbers")
+	f(p)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.GetProperty(tt.key); got != tt.want {
+				t.Errorf("GetProperty(%v) = %v, want %v", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_extractKVMap(t *testing.T) {
+	timestamp := time.Date(2001, 12, 14, 21, 59, 43, 100000000, time.UTC)
+	input := map[string]interface{}{
+		"int":     int(-7),
+		"int64":   int64(-9223372036854775808),
+		"uint64":  uint64(18446744073709551615),
+		"float":   float64(12345678901234567890),
+		"number":  json.Number("1.50"),
+		"time":    timestamp,
+		"binary":  []byte("hello"),
+		"boolean": false,
+	}
+	want := map[string]string{
+		"int":     "-7",
+		"int64":   "-9223372036854775808",
+		"uint64":  "18446744073709551615",
+		"float":   "12345678901234567000",
+		"number":  "1.50",
+		"time":    "2001-12-14T21:59:43.1Z",
+		"binary":  "aGVsbG8=",
+		"boolean": "false",
+	}
+	p := EmptyProperties()
+	extractKVMap(p, input, "")
+	if !reflect.DeepEqual(p.keyValueMap, want) {
+		t.Errorf("extractKVMap() = %v, want %v", p.keyValueMap, want)
+	}
+}
+
+func TestMultiDocumentYAML(t *testing.T) {
+	p := EmptyProperties()
+	GlobalPropertyLoader("testdata/resources/multidoc")(p)
+	if got := p.GetProperty("document"); got != "second" {
+		t.Errorf("before profiles, document = %v, want %v", got, "second")
+	}
+	if len(p.pending) != 2 {
+		t.Errorf("pending documents = %v, want %v", len(p.pending), 2)
+	}
+	ProfilePropertyLoader("testdata/resources/multidoc")(p)
+	tests := []struct {
+		name string
+		key  string
+		want string
+	}{
+		{"later documents override", "document", "development"},
+		{"activation key is not a property", "on-profile", ""},
+		{"later plain document overrides", "level", "plain"},
+		{"held back document still applies", "size", "large"},
+		{"profile file document", "region", "dev"},
+		{"anchor", "base.host", "localhost"},
+		{"merge key", "server.host", "localhost"},
+		{"merge key nested", "server.pool.size", "4"},
+		{"explicit key beats merge", "server.port", "9090"},
+		{"first merged mapping wins", "client.port", "7070"},
+		{"second merged mapping", "client.host", "localhost"},
+		{"first merged mapping only", "client.timeout", "30"},
+		{"alias", "copy.port", "8080"},
+		{"alias nested", "copy.pool.size", "4"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.GetProperty(tt.key); got != tt.want {
+				t.Errorf("GetProperty(%v) = %v, want %v", tt.key, got, tt.want)
+			}
+		})
+	}
+}
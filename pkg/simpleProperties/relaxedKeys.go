@@ -0,0 +1,122 @@
+package simpleProperties
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// SetRelaxedKeys choose whether keys differing only in how their words are written name the same property. With
+// relaxed keys, server.maxConnections, server.max-connections, server.max_connections and the environment variable
+// SERVER_MAX_CONNECTIONS are all the property server.max-connections, found by GetProperty with any of the names.
+// Keys are stored in lower case kebab-case, so server.maxConnections is server.max-connections
+//
+// An upper case environment variable name says nothing of where its words split into parts of a dotted key, so it
+// takes the name of a property already set with the same words, or otherwise has each _ read as a dot. Two keys with
+// the same words split differently, such as server.max-connections and server.max.connections, are reported by Load
+// as a collision. Choose relaxed keys before loading
+func (p *Properties) SetRelaxedKeys(relaxed bool) {
+	p.relaxedKeys = relaxed
+}
+
+// a key stored with relaxed keys, indexed by its words
+type relaxedKey struct {
+	key      string // the name it is stored under
+	spelling string // the name it was first set with
+	source   string // where it was first set, if known
+	guessed  bool   // whether key was guessed from an environment variable name
+}
+
+// the name a property is stored under, following any aliases
+func (p *Properties) storedKey(name string) string {
+	return p.aliasTarget(p.relaxedName(name))
+}
+
+// the name a key is stored under with relaxed keys, before following any aliases
+func (p *Properties) relaxedName(name string) string {
+	if !p.relaxedKeys {
+		return name
+	}
+	if known, found := p.relaxedIndex[looseKey(name)]; found {
+		return known.key
+	}
+	key, _ := canonicalKey(name)
+	return key
+}
+
+// the name a key being loaded or set is stored under with relaxed keys, recording it so that other spellings find
+// it. keys with the same words split differently are reported as a collision
+func (p *Properties) relaxKey(name string, source string) string {
+	if !p.relaxedKeys {
+		return name
+	}
+	key, guessed := canonicalKey(name)
+	loose := looseKey(name)
+	known, found := p.relaxedIndex[loose]
+	if !found {
+		if p.relaxedIndex == nil {
+			p.relaxedIndex = make(map[string]relaxedKey)
+		}
+		p.relaxedIndex[loose] = relaxedKey{key: key, spelling: name, source: source, guessed: guessed}
+		return key
+	}
+	if known.key != key && !known.guessed && !guessed {
+		p.errs = append(p.errs, fmt.Errorf("%s and %s collide as relaxed keys",
+			describeSource(known.source, known.spelling), describeSource(source, name)))
+	}
+	return known.key
+}
+
+// a key with its case and separators removed, leaving its words, e.g. servermaxconnections
+func looseKey(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '.' || r == '-' || r == '_' {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, name)
+}
+
+// a key in lower case kebab-case, e.g. server.max-connections for server.maxConnections, and whether it was guessed
+// from an environment variable name such as SERVER_PORT, where each _ is read as a dot
+func canonicalKey(name string) (string, bool) {
+	if isEnvironmentName(name) {
+		return strings.ToLower(strings.ReplaceAll(name, "_", ".")), true
+	}
+	b := strings.Builder{}
+	runes := []rune(name)
+	for i, r := range runes {
+		switch {
+		case r == '_':
+			b.WriteRune('-')
+		case unicode.IsUpper(r):
+			previous, next := rune(0), rune(0)
+			if i > 0 {
+				previous = runes[i-1]
+			}
+			if i+1 < len(runes) {
+				next = runes[i+1]
+			}
+			if unicode.IsLower(previous) || unicode.IsDigit(previous) || unicode.IsUpper(previous) && unicode.IsLower(next) {
+				b.WriteRune('-')
+			}
+			b.WriteRune(unicode.ToLower(r))
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String(), false
+}
+
+// whether a key is written as an environment variable, in upper case with _ between words, e.g. SERVER_PORT
+func isEnvironmentName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if !unicode.IsUpper(r) && !unicode.IsDigit(r) && r != '_' {
+			return false
+		}
+	}
+	return true
+}
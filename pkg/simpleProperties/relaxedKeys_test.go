@@ -0,0 +1,110 @@
+package simpleProperties
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func Test_canonicalKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		guessed bool
+	}{
+		{"server.maxConnections", "server.max-connections", false},
+		{"server.max-connections", "server.max-connections", false},
+		{"server.max_connections", "server.max-connections", false},
+		{"Server.MaxConnections", "server.max-connections", false},
+		{"server.HTTPPort", "server.http-port", false},
+		{"server.http2Enabled", "server.http2-enabled", false},
+		{"SERVER_MAX_CONNECTIONS", "server.max.connections", true},
+		{"HOME", "home", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, guessed := canonicalKey(tt.name)
+			if got != tt.want || guessed != tt.guessed {
+				t.Errorf("canonicalKey() = %s, %t, want %s, %t", got, guessed, tt.want, tt.guessed)
+			}
+		})
+	}
+}
+
+func TestRelaxedKeys(t *testing.T) {
+	p := EmptyProperties()
+	p.SetEvaluators(DefaultEvaluator())
+	p.SetRelaxedKeys(true)
+	setKVFrom(p, "server.maxConnections", "10", "application.yaml")
+	setKVFrom(p, "server.max_connections", "20", "application.properties")
+	setKVFrom(p, "SERVER_MAX_CONNECTIONS", "30", environmentSource)
+	setKVFrom(p, "SERVER_PORT", "8080", environmentSource)
+	p.Set("url", "http://localhost:${server.port}/${Server.MaxConnections}")
+	if err := p.Evaluate(); err != nil {
+		t.Fatalf("Evaluate() error = %v", err)
+	}
+	for _, key := range []string{"server.max-connections", "server.maxConnections", "server.max_connections", "SERVER_MAX_CONNECTIONS"} {
+		if got := p.GetProperty(key); got != "30" {
+			t.Errorf("GetProperty(%s) = %s, want 30", key, got)
+		}
+	}
+	keys := p.GetKeys()
+	sort.Strings(keys)
+	if want := []string{"server.max-connections", "server.port", "url"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("GetKeys() = %v, want %v", keys, want)
+	}
+	if got := p.GetProperty("url"); got != "http://localhost:8080/30" {
+		t.Errorf("url = %s", got)
+	}
+
+	// the same words split differently collide
+	setKVFrom(p, "server.max.connections", "40", "override.properties")
+	want := []string{"application.yaml: server.maxConnections and override.properties: server.max.connections collide as relaxed keys"}
+	if got := violations(p.Evaluate()); !reflect.DeepEqual(got, want) {
+		t.Errorf("Evaluate() errors %v, want %v", got, want)
+	}
+
+	// aliases are relaxed too
+	p.Alias("server.connectionLimit", "server.maxConnections")
+	p.SetLogger(&recordingLogger{})
+	if got := p.GetProperty("SERVER_CONNECTION_LIMIT"); got != "40" {
+		t.Errorf("GetProperty(SERVER_CONNECTION_LIMIT) = %s, want 40", got)
+	}
+}
+
+func TestRelaxedKeysEvaluationOrder(t *testing.T) {
+	p := EmptyProperties()
+	p.SetEvaluators(DefaultEvaluator())
+	p.SetRelaxedKeys(true)
+	p.Merge(map[string]string{"a": "${z.fooBar}", "z.fooBar": "${b}", "b": "x", "c": "${prop:Z.FOO_BAR:none}"})
+	if err := p.Evaluate(); err != nil {
+		t.Fatalf("Evaluate() error = %v", err)
+	}
+	for key, want := range map[string]string{"a": "x", "z.foo-bar": "x", "c": "x"} {
+		if got := p.GetProperty(key); got != want {
+			t.Errorf("GetProperty(%s) = %q, want %q", key, got, want)
+		}
+	}
+	if got, want := p.Dependents("b"), []string{"a", "c", "z.foo-bar"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Dependents(b) = %v, want %v", got, want)
+	}
+	if got, want := p.DependsOn("a"), []string{"z.foo-bar"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("DependsOn(a) = %v, want %v", got, want)
+	}
+
+	// a cycle through another spelling is still a cycle
+	p.Set("b", "${a}")
+	want := []string{"property cycle a -> z.foo-bar -> b -> a"}
+	if got := violations(p.Evaluate()); !reflect.DeepEqual(got, want) {
+		t.Errorf("Evaluate() errors %v, want %v", got, want)
+	}
+}
+
+func TestStrictKeys(t *testing.T) {
+	p := EmptyProperties()
+	p.Set("server.maxConnections", "10")
+	p.Set("server.max-connections", "20")
+	if got := p.GetProperty("server.maxConnections"); got != "10" {
+		t.Errorf("without relaxed keys, expected distinct keys, got %s", got)
+	}
+}
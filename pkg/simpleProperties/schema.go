@@ -0,0 +1,430 @@
+package simpleProperties
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema the rules for the properties of an application, keyed by property name
+//
+//	schema := Schema{
+//		"server.port": Int().Range(1, 65535).Required(),
+//		"server.mode": String().Enum("dev", "prod"),
+//		"db.url":      URL().ExclusiveWith("db.host"),
+//	}
+type Schema map[string]*Rule
+
+// rule kinds
+const (
+	kindString   = "string"
+	kindInt      = "integer"
+	kindFloat    = "number"
+	kindBool     = "boolean"
+	kindDuration = "duration"
+	kindURL      = "url"
+	kindHostPort = "host:port"
+	kindObject   = "object" // a group of properties sharing a prefix, from a JSON schema
+)
+
+// Rule the expectations for one property. Build one with String, Int, Float, Bool, Duration, URL or HostPort,
+// then add to it with the methods of Rule
+type Rule struct {
+	kind         string
+	required     bool
+	requiredIn   string // required only if another property starting with this prefix is set
+	min, max     *float64
+	minLength    int
+	maxLength    int // -1 for no limit
+	pattern      *regexp.Regexp
+	enum         []string
+	exclusive    []string
+	description  string
+	defaultValue *string
+}
+
+func newRule(kind string) *Rule {
+	return &Rule{kind: kind, maxLength: -1}
+}
+
+// String a property holding any text
+func String() *Rule { return newRule(kindString) }
+
+// Int a property holding a whole number
+func Int() *Rule { return newRule(kindInt) }
+
+// Float a property holding a number
+func Float() *Rule { return newRule(kindFloat) }
+
+// Bool a property holding true or false, in any of the forms accepted by strconv.ParseBool
+func Bool() *Rule { return newRule(kindBool) }
+
+// Duration a property holding a duration such as 1m30s, as accepted by time.ParseDuration
+func Duration() *Rule { return newRule(kindDuration) }
+
+// URL a property holding an absolute URL, with a scheme and host
+func URL() *Rule { return newRule(kindURL) }
+
+// HostPort a property holding host:port, with a port from 0 to 65535
+func HostPort() *Rule { return newRule(kindHostPort) }
+
+// Required the property must have a value
+func (r *Rule) Required() *Rule {
+	r.required = true
+	return r
+}
+
+// Range the value must be a number from min to max, inclusive
+func (r *Rule) Range(min float64, max float64) *Rule {
+	return r.Min(min).Max(max)
+}
+
+// Min the value must be a number of at least min
+func (r *Rule) Min(min float64) *Rule {
+	r.min = &min
+	return r
+}
+
+// Max the value must be a number of at most max
+func (r *Rule) Max(max float64) *Rule {
+	r.max = &max
+	return r
+}
+
+// Length the value must have from min to max characters. a max below 0 means no limit
+func (r *Rule) Length(min int, max int) *Rule {
+	r.minLength, r.maxLength = min, max
+	return r
+}
+
+// Pattern the value must match a regular expression somewhere, so use ^ and $ to match the whole value. panics if
+// the expression is invalid, as regexp.MustCompile does
+func (r *Rule) Pattern(expression string) *Rule {
+	r.pattern = regexp.MustCompile(expression)
+	return r
+}
+
+// Enum the value must be one of those listed
+func (r *Rule) Enum(values ...string) *Rule {
+	r.enum = values
+	return r
+}
+
+// ExclusiveWith the property must not have a value when any of the keys listed does
+func (r *Rule) ExclusiveWith(keys ...string) *Rule {
+	r.exclusive = append(r.exclusive, keys...)
+	return r
+}
+
+// Description what the property is for. not checked, but kept for generating documentation
+func (r *Rule) Description(text string) *Rule {
+	r.description = text
+	return r
+}
+
+// Default the value used when the property is not set. not applied, but kept for generating documentation
+func (r *Rule) Default(value string) *Rule {
+	r.defaultValue = &value
+	return r
+}
+
+// Violation a property that breaks a rule in a schema
+type Violation struct {
+	Key     string
+	Source  string // the file or other source the property came from, when known
+	Message string
+}
+
+func (v *Violation) Error() string {
+	if v.Source != "" {
+		return v.Source + ": " + v.Key + ": " + v.Message
+	}
+	return v.Key + ": " + v.Message
+}
+
+// SetSchema check the properties against a schema each time they are evaluated, so Load and Evaluate return any
+// violations along with other problems. nil for no checks
+func (p *Properties) SetSchema(s Schema) {
+	p.schema = s
+}
+
+// Validate check evaluated properties against a schema, returning every violation together as a *LoadError, or
+// nil if there are none
+func (s Schema) Validate(p *Properties) error {
+	return newLoadError(s.violations(p))
+}
+
+// the violations of a schema, in key order
+func (s Schema) violations(p *Properties) []error {
+	keys := make([]string, 0, len(s))
+	for key := range s {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	errs := []error{}
+	for _, key := range keys {
+		for _, message := range s[key].check(p, key) {
+			errs = append(errs, &Violation{Key: key, Source: p.sources[key], Message: message})
+		}
+	}
+	return errs
+}
+
+// the ways a property breaks a rule
+func (r *Rule) check(p *Properties, key string) []string {
+	if r.kind == kindObject {
+		if r.required && !hasPrefixedKey(p, key+".") {
+			return []string{"is required"}
+		}
+		return nil
+	}
+	value, found := p.lookup(key)
+	if !found {
+		switch {
+		case p.evalKeyValueMap[key] != "":
+			return []string{fmt.Sprintf("has unresolved expressions: %s", p.evalKeyValueMap[key])}
+		case r.required, r.requiredIn != "" && hasPrefixedKey(p, r.requiredIn+"."):
+			return []string{"is required"}
+		}
+		return nil
+	}
+	messages := []string{}
+	for _, other := range r.exclusive {
+		if _, set := p.lookup(other); set {
+			messages = append(messages, fmt.Sprintf("cannot be set with %s", other))
+		}
+	}
+	if message := r.checkKind(value); message != "" {
+		return append(messages, message)
+	}
+	if r.min != nil || r.max != nil {
+		if n, err := strconv.ParseFloat(value, 64); err != nil {
+			messages = append(messages, fmt.Sprintf("%q is not a number", value))
+		} else if r.min != nil && n < *r.min {
+			messages = append(messages, fmt.Sprintf("%s is less than %s", value, formatNumber(*r.min)))
+		} else if r.max != nil && n > *r.max {
+			messages = append(messages, fmt.Sprintf("%s is more than %s", value, formatNumber(*r.max)))
+		}
+	}
+	length := len([]rune(value))
+	if length < r.minLength {
+		messages = append(messages, fmt.Sprintf("%q is shorter than %d characters", value, r.minLength))
+	}
+	if r.maxLength >= 0 && length > r.maxLength {
+		messages = append(messages, fmt.Sprintf("%q is longer than %d characters", value, r.maxLength))
+	}
+	if r.pattern != nil && !r.pattern.MatchString(value) {
+		messages = append(messages, fmt.Sprintf("%q does not match %s", value, r.pattern))
+	}
+	if len(r.enum) > 0 && !containsString(r.enum, value) {
+		messages = append(messages, fmt.Sprintf("%q is not one of %s", value, strings.Join(r.enum, ", ")))
+	}
+	return messages
+}
+
+// why a value is not of the kind of the rule, or empty if it is
+func (r *Rule) checkKind(value string) string {
+	var err error
+	switch r.kind {
+	case kindInt:
+		_, err = strconv.ParseInt(value, 10, 64)
+	case kindFloat:
+		_, err = strconv.ParseFloat(value, 64)
+	case kindBool:
+		_, err = strconv.ParseBool(value)
+	case kindDuration:
+		_, err = time.ParseDuration(value)
+	case kindURL:
+		var u *url.URL
+		if u, err = url.Parse(value); err == nil && (u.Scheme == "" || u.Host == "") {
+			err = fmt.Errorf("no scheme or host")
+		}
+	case kindHostPort:
+		var port string
+		if _, port, err = net.SplitHostPort(value); err == nil {
+			if n, portErr := strconv.ParseUint(port, 10, 16); portErr != nil || n > 65535 {
+				err = fmt.Errorf("bad port")
+			}
+		}
+	}
+	if err != nil {
+		return fmt.Sprintf("%q is not a valid %s", value, r.kind)
+	}
+	return ""
+}
+
+func hasPrefixedKey(p *Properties, prefix string) bool {
+	for _, m := range []map[string]string{p.keyValueMap, p.bootKeyValueMap} {
+		for k := range m {
+			if strings.HasPrefix(k, prefix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
+
+// LoadSchemaFile read a schema from a JSON Schema file, as described for LoadSchema
+func LoadSchemaFile(path string) (Schema, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	s, err := LoadSchema(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+// LoadSchema read a schema from a JSON Schema document. Nested objects give dotted keys, so server.port is the
+// port property of the server object. The keywords used are type, properties, required, minimum, maximum,
+// minLength, maxLength, pattern, enum, format (uri, duration or host-port), description, default and, as an
+// extension, exclusiveWith, a list of keys. Others are ignored
+func LoadSchema(r io.Reader) (Schema, error) {
+	var root jsonSchema
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	if err := decoder.Decode(&root); err != nil {
+		return nil, err
+	}
+	if root.typeName() != kindObject {
+		return nil, fmt.Errorf("a schema must describe an object")
+	}
+	s := Schema{}
+	if err := root.flatten(s, "", true); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// the parts of JSON Schema read by LoadSchema
+type jsonSchema struct {
+	Type          interface{}            `json:"type"`
+	Properties    map[string]*jsonSchema `json:"properties"`
+	Required      []string               `json:"required"`
+	Minimum       *float64               `json:"minimum"`
+	Maximum       *float64               `json:"maximum"`
+	MinLength     *int                   `json:"minLength"`
+	MaxLength     *int                   `json:"maxLength"`
+	Pattern       string                 `json:"pattern"`
+	Enum          []interface{}          `json:"enum"`
+	Format        string                 `json:"format"`
+	Description   string                 `json:"description"`
+	Default       interface{}            `json:"default"`
+	ExclusiveWith []string               `json:"exclusiveWith"`
+}
+
+// the type named by a schema, ignoring null in a list of types
+func (j *jsonSchema) typeName() string {
+	switch t := j.Type.(type) {
+	case string:
+		return t
+	case []interface{}:
+		for _, name := range t {
+			if s, isString := name.(string); isString && s != "null" {
+				return s
+			}
+		}
+	}
+	if len(j.Properties) > 0 {
+		return kindObject
+	}
+	return kindString
+}
+
+// add the rules for a schema, and the objects within it, to s. as in JSON Schema, the properties required by an
+// object that is not itself required are only needed when some other property of the object is set
+func (j *jsonSchema) flatten(s Schema, key string, required bool) error {
+	kind := j.typeName()
+	if kind == kindObject {
+		if key != "" {
+			s[key] = newRule(kindObject)
+			s[key].required = required
+			s[key].description = j.Description
+		}
+		for name, child := range j.Properties {
+			childKey := name
+			if key != "" {
+				childKey = key + "." + name
+			}
+			childRequired := containsString(j.Required, name)
+			if err := child.flatten(s, childKey, childRequired && required); err != nil {
+				return err
+			}
+			if childRequired && !required {
+				s[childKey].requiredIn = key
+			}
+		}
+		return nil
+	}
+	var rule *Rule
+	switch kind {
+	case kindString:
+		switch j.Format {
+		case "uri", "url":
+			rule = URL()
+		case "duration":
+			rule = Duration()
+		case "host-port":
+			rule = HostPort()
+		default:
+			rule = String()
+		}
+	case kindInt:
+		rule = Int()
+	case kindFloat:
+		rule = Float()
+	case kindBool:
+		rule = Bool()
+	default:
+		return fmt.Errorf("%s: unsupported type %s", key, kind)
+	}
+	rule.required = required
+	rule.min, rule.max = j.Minimum, j.Maximum
+	if j.MinLength != nil {
+		rule.minLength = *j.MinLength
+	}
+	if j.MaxLength != nil {
+		rule.maxLength = *j.MaxLength
+	}
+	if j.Pattern != "" {
+		pattern, err := regexp.Compile(j.Pattern)
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		rule.pattern = pattern
+	}
+	for _, v := range j.Enum {
+		rule.enum = append(rule.enum, fmt.Sprint(v))
+	}
+	rule.exclusive = j.ExclusiveWith
+	rule.description = j.Description
+	if j.Default != nil {
+		rule.Default(fmt.Sprint(j.Default))
+	}
+	s[key] = rule
+	return nil
+}
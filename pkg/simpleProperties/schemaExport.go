@@ -0,0 +1,437 @@
+package simpleProperties
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SchemaFromStruct build a schema from the fields of a struct, or a pointer to one. Each field gives a property
+// named as it would be when the struct's data is loaded from a YAML or JSON file, so nested structs give dotted
+// keys, exactly as for the files themselves. The key is taken from the property tag, then the yaml tag, then the
+// json tag. Otherwise it is the field name in lower case, so MaxConnections is maxconnections, as yaml.v3 names it
+// and encoding/json, ignoring case, matches it. A field is left out when the tag its key would come from is "-"
+//
+//	type Config struct {
+//		Server struct {
+//			Port int           `property:"port,required" default:"8080" min:"1" max:"65535"`
+//			Mode string        `enum:"dev,prod" description:"how strict to be"`
+//			Timeout time.Duration
+//		}
+//		Internal string `property:"-"`
+//	}
+//
+// The tags are property (the key, then options: required), description, default, min, max, pattern and enum,
+// a comma separated list. Fields of embedded structs, and of structs tagged yaml:",inline", are treated as fields of
+// the outer struct. Strings, numbers, booleans, time.Duration and url.URL are supported, with a slice of strings
+// held as a comma separated list. A struct holding a required field is itself required. A struct that holds itself,
+// directly or through other structs, is an error, as it would have keys without end
+func SchemaFromStruct(v interface{}) (Schema, error) {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("SchemaFromStruct needs a struct, not %v", t)
+	}
+	s := Schema{}
+	if _, err := structRules(s, t, "", map[reflect.Type]bool{}); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	urlType      = reflect.TypeOf(url.URL{})
+)
+
+// add the rules for the fields of a struct to s, with keys starting with prefix, and report if any is required.
+// A struct holding a required field is itself required. walking holds the structs being walked, as a struct that
+// holds itself, such as a linked list node, would give keys without end
+func structRules(s Schema, t reflect.Type, prefix string, walking map[reflect.Type]bool) (bool, error) {
+	walking[t] = true
+	defer delete(walking, t)
+	required := false
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, options, skip, inline := structFieldKey(field)
+		if skip || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if walking[fieldType] {
+			return false, fmt.Errorf("%s: recursive type %v", prefix+strings.ToLower(field.Name), field.Type)
+		}
+		if inline && fieldType.Kind() == reflect.Struct {
+			embeddedRequired, err := structRules(s, fieldType, prefix, walking)
+			if err != nil {
+				return false, err
+			}
+			required = required || embeddedRequired
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		key := prefix + name
+		var rule *Rule
+		switch {
+		case fieldType == durationType:
+			rule = Duration()
+		case fieldType == urlType:
+			rule = URL()
+		case fieldType.Kind() == reflect.Struct:
+			rule = newRule(kindObject)
+			fieldsRequired, err := structRules(s, fieldType, key+".", walking)
+			if err != nil {
+				return false, err
+			}
+			rule.required = fieldsRequired
+		case fieldType.Kind() == reflect.String:
+			rule = String()
+		case fieldType.Kind() == reflect.Bool:
+			rule = Bool()
+		case fieldType.Kind() >= reflect.Int && fieldType.Kind() <= reflect.Uint64:
+			rule = Int()
+		case fieldType.Kind() == reflect.Float32 || fieldType.Kind() == reflect.Float64:
+			rule = Float()
+		case fieldType.Kind() == reflect.Slice && fieldType.Elem().Kind() == reflect.String:
+			rule = String()
+		default:
+			return false, fmt.Errorf("%s: unsupported type %v", key, field.Type)
+		}
+		if err := applyTags(rule, field.Tag, options); err != nil {
+			return false, fmt.Errorf("%s: %w", key, err)
+		}
+		s[key] = rule
+		required = required || rule.required
+	}
+	return required, nil
+}
+
+// the key for a field and the options of its property tag. the key is taken from the property tag, then the yaml
+// tag, then the json tag. with none of these it is empty, for the field name in lower case. skip is set for a field
+// tagged "-", and inline for a field whose fields belong to the outer struct: one tagged yaml:",inline", or an
+// embedded struct with no tag
+func structFieldKey(field reflect.StructField) (name string, options string, skip bool, inline bool) {
+	if tag, found := field.Tag.Lookup("property"); found {
+		name, options, _ = strings.Cut(tag, ",")
+		return name, options, tag == "-", false
+	}
+	tagged := false
+	for _, library := range []string{"yaml", "json"} {
+		tag, found := field.Tag.Lookup(library)
+		if !found {
+			continue
+		}
+		tagged = true
+		name, flags, _ := strings.Cut(tag, ",")
+		if tag == "-" {
+			return "", "", true, false
+		}
+		if library == "yaml" && strings.Contains(","+flags+",", ",inline,") {
+			return "", "", false, true
+		}
+		if name != "" {
+			return name, "", false, false
+		}
+	}
+	return "", "", false, field.Anonymous && !tagged
+}
+
+func applyTags(rule *Rule, tag reflect.StructTag, options string) error {
+	for _, option := range strings.Split(options, ",") {
+		switch strings.TrimSpace(option) {
+		case "":
+		case "required":
+			rule.Required()
+		default:
+			return fmt.Errorf("unknown property tag option %s", option)
+		}
+	}
+	rule.Description(tag.Get("description"))
+	if v, found := tag.Lookup("default"); found {
+		rule.Default(v)
+	}
+	for _, limit := range []struct {
+		name string
+		set  func(float64) *Rule
+	}{{"min", rule.Min}, {"max", rule.Max}} {
+		if v, found := tag.Lookup(limit.name); found {
+			n, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return fmt.Errorf("%s tag %q is not a number", limit.name, v)
+			}
+			limit.set(n)
+		}
+	}
+	if v, found := tag.Lookup("pattern"); found {
+		if err := rule.setPattern(v); err != nil {
+			return err
+		}
+	}
+	if v, found := tag.Lookup("enum"); found {
+		rule.Enum(splitList(v)...)
+	}
+	return nil
+}
+
+// set the pattern of a rule, reporting rather than panicking if it is invalid
+func (r *Rule) setPattern(expression string) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("%v", recovered)
+		}
+	}()
+	r.Pattern(expression)
+	return nil
+}
+
+// WriteJSONSchema write a schema as a JSON Schema document, with dotted keys as nested objects. The document can
+// be read back with LoadSchema, and given to an editor to check and complete application.yaml or .json files
+func (s Schema) WriteJSONSchema(w io.Writer) error {
+	root := map[string]interface{}{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"type":    kindObject,
+	}
+	for _, key := range s.sortedKeys() {
+		parent := root
+		parts := strings.Split(key, ".")
+		for i, part := range parts[:len(parts)-1] {
+			parent = jsonSchemaChild(parent, part, s[strings.Join(parts[:i+1], ".")])
+		}
+		rule := s[key]
+		if rule.kind == kindObject {
+			jsonSchemaChild(parent, parts[len(parts)-1], rule)
+			continue
+		}
+		properties := jsonSchemaProperties(parent)
+		properties[parts[len(parts)-1]] = rule.jsonSchema()
+		if rule.required || rule.requiredIn != "" {
+			parent["required"] = append(parent["required"].([]string), parts[len(parts)-1])
+		}
+	}
+	buffer := &bytes.Buffer{}
+	encoder := json.NewEncoder(buffer)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(root); err != nil {
+		return err
+	}
+	_, err := buffer.WriteTo(w)
+	return err
+}
+
+func (s Schema) sortedKeys() []string {
+	keys := make([]string, 0, len(s))
+	for key := range s {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// the properties of an object in a JSON schema, creating them if needed
+func jsonSchemaProperties(object map[string]interface{}) map[string]interface{} {
+	properties, found := object["properties"].(map[string]interface{})
+	if !found {
+		properties = make(map[string]interface{})
+		object["properties"] = properties
+		object["required"] = []string{}
+	}
+	return properties
+}
+
+// the child object of an object in a JSON schema, creating it if needed. rule may be nil for an object with no
+// rule of its own
+func jsonSchemaChild(parent map[string]interface{}, name string, rule *Rule) map[string]interface{} {
+	properties := jsonSchemaProperties(parent)
+	child, found := properties[name].(map[string]interface{})
+	if !found {
+		child = map[string]interface{}{"type": kindObject}
+		jsonSchemaProperties(child)
+		properties[name] = child
+		if rule != nil && rule.required {
+			parent["required"] = append(parent["required"].([]string), name)
+		}
+	}
+	if rule != nil && rule.description != "" {
+		child["description"] = rule.description
+	}
+	return child
+}
+
+// a rule as a JSON schema, using the keywords read by LoadSchema
+func (r *Rule) jsonSchema() map[string]interface{} {
+	j := map[string]interface{}{"type": r.kind}
+	switch r.kind {
+	case kindURL:
+		j["type"], j["format"] = kindString, "uri"
+	case kindDuration:
+		j["type"], j["format"] = kindString, "duration"
+	case kindHostPort:
+		j["type"], j["format"] = kindString, "host-port"
+	}
+	if r.min != nil {
+		j["minimum"] = *r.min
+	}
+	if r.max != nil {
+		j["maximum"] = *r.max
+	}
+	if r.minLength > 0 {
+		j["minLength"] = r.minLength
+	}
+	if r.maxLength >= 0 {
+		j["maxLength"] = r.maxLength
+	}
+	if r.pattern != nil {
+		j["pattern"] = r.pattern.String()
+	}
+	if len(r.enum) > 0 {
+		j["enum"] = r.enum
+	}
+	if len(r.exclusive) > 0 {
+		j["exclusiveWith"] = r.exclusive
+	}
+	if r.description != "" {
+		j["description"] = r.description
+	}
+	if r.defaultValue != nil {
+		j["default"] = r.typedDefault()
+	}
+	return j
+}
+
+// the default of a rule as a JSON value of the rule's type, or as text if it doesn't parse
+func (r *Rule) typedDefault() interface{} {
+	v := *r.defaultValue
+	switch r.kind {
+	case kindInt:
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return n
+		}
+	case kindFloat:
+		if n, err := strconv.ParseFloat(v, 64); err == nil {
+			return n
+		}
+	case kindBool:
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+	return v
+}
+
+// WriteTemplate write a reference file for the properties in a schema, in FormatProperties or FormatYAML. Each
+// property is given its default, or left empty, with a comment holding its description and rules
+func (s Schema) WriteTemplate(w io.Writer, format Format) error {
+	buffer := &bytes.Buffer{}
+	switch format {
+	case FormatProperties:
+		for _, key := range s.sortedKeys() {
+			rule := s[key]
+			if rule.kind == kindObject {
+				continue
+			}
+			fmt.Fprintf(buffer, "# %s\n%s=%s\n", rule.comment(), escapeProperty(key, true), escapeProperty(rule.defaultText(), false))
+		}
+	case FormatYAML:
+		root := &yaml.Node{Kind: yaml.MappingNode}
+		for _, key := range s.sortedKeys() {
+			rule := s[key]
+			parent := root
+			parts := strings.Split(key, ".")
+			for _, part := range parts[:len(parts)-1] {
+				parent = yamlChild(parent, part)
+			}
+			if rule.kind == kindObject {
+				yamlChild(parent, parts[len(parts)-1])
+				yamlKeyNode(parent, parts[len(parts)-1]).HeadComment = rule.description
+				continue
+			}
+			keyNode := yamlScalar(parts[len(parts)-1])
+			keyNode.HeadComment = rule.comment()
+			parent.Content = append(parent.Content, keyNode, yamlScalar(rule.defaultText()))
+		}
+		if err := writeYAML(buffer, root); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("templates can't be written as %s", format)
+	}
+	_, err := buffer.WriteTo(w)
+	return err
+}
+
+// the mapping held by a key of a yaml mapping, creating it if needed
+func yamlChild(parent *yaml.Node, name string) *yaml.Node {
+	for i := 0; i < len(parent.Content); i += 2 {
+		if parent.Content[i].Value == name {
+			return parent.Content[i+1]
+		}
+	}
+	child := &yaml.Node{Kind: yaml.MappingNode}
+	parent.Content = append(parent.Content, yamlScalar(name), child)
+	return child
+}
+
+// the key node for a name in a yaml mapping, or nil
+func yamlKeyNode(parent *yaml.Node, name string) *yaml.Node {
+	for i := 0; i < len(parent.Content); i += 2 {
+		if parent.Content[i].Value == name {
+			return parent.Content[i]
+		}
+	}
+	return nil
+}
+
+// the default value of a rule, or empty
+func (r *Rule) defaultText() string {
+	if r.defaultValue == nil {
+		return ""
+	}
+	return *r.defaultValue
+}
+
+// describe a rule for a template comment, e.g. the service port (integer, required, 1 to 65535)
+func (r *Rule) comment() string {
+	facts := []string{r.kind}
+	if r.required {
+		facts = append(facts, "required")
+	}
+	switch {
+	case r.min != nil && r.max != nil:
+		facts = append(facts, formatNumber(*r.min)+" to "+formatNumber(*r.max))
+	case r.min != nil:
+		facts = append(facts, "at least "+formatNumber(*r.min))
+	case r.max != nil:
+		facts = append(facts, "at most "+formatNumber(*r.max))
+	}
+	if r.pattern != nil {
+		facts = append(facts, "matching "+r.pattern.String())
+	}
+	if len(r.enum) > 0 {
+		facts = append(facts, "one of "+strings.Join(r.enum, ", "))
+	}
+	if len(r.exclusive) > 0 {
+		facts = append(facts, "not with "+strings.Join(r.exclusive, ", "))
+	}
+	comment := "(" + strings.Join(facts, ", ") + ")"
+	if r.description != "" {
+		comment = r.description + " " + comment
+	}
+	return comment
+}
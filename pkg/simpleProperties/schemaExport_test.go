@@ -0,0 +1,208 @@
+package simpleProperties
+
+import (
+	"bytes"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type exportLimits struct {
+	Connections int `property:"max-connections" min:"1" max:"100" default:"10"`
+}
+
+type exportConfig struct {
+	exportLimits
+	Server struct {
+		Port    int           `property:"port,required" description:"the port to listen on" min:"1" max:"65535" default:"8080"`
+		Mode    string        `enum:"dev,prod" default:"dev"`
+		Timeout time.Duration `description:"how long to wait"`
+	} `description:"the http server"`
+	Database *struct {
+		URL  url.URL `property:"url,required"`
+		User string  `pattern:"^[a-z]+$"`
+	}
+	Tags     []string
+	Ratio    float64
+	Debug    bool
+	Internal string `property:"-"`
+	internal string
+}
+
+func TestSchemaFromStruct(t *testing.T) {
+	s, err := SchemaFromStruct(&exportConfig{})
+	if err != nil {
+		t.Fatalf("SchemaFromStruct: %v", err)
+	}
+	want := Schema{
+		"max-connections": Int().Range(1, 100).Default("10"),
+		"server":          newRule(kindObject).Required().Description("the http server"),
+		"server.port":     Int().Required().Description("the port to listen on").Range(1, 65535).Default("8080"),
+		"server.mode":     String().Enum("dev", "prod").Default("dev"),
+		"server.timeout":  Duration().Description("how long to wait"),
+		"database":        newRule(kindObject).Required(),
+		"database.url":    URL().Required(),
+		"database.user":   String().Pattern("^[a-z]+$"),
+		"tags":            String(),
+		"ratio":           Float(),
+		"debug":           Bool(),
+	}
+	if !reflect.DeepEqual(s, want) {
+		for key := range want {
+			if !reflect.DeepEqual(s[key], want[key]) {
+				t.Errorf("%s: got %+v, want %+v", key, s[key], want[key])
+			}
+		}
+		t.Errorf("got keys %v, want %v", s.sortedKeys(), want.sortedKeys())
+	}
+	p := EmptyProperties()
+	p.SetEvaluators(DefaultEvaluator())
+	p.SetSchema(s)
+	p.Merge(map[string]string{"server.port": "0", "database.url": "postgres://db"})
+	if got := violations(p.Evaluate()); !reflect.DeepEqual(got, []string{"server.port: 0 is less than 1"}) {
+		t.Errorf("violations %v", got)
+	}
+}
+
+func TestSchemaFromStructNames(t *testing.T) {
+	type pool struct {
+		MinIdle int
+	}
+	s, err := SchemaFromStruct(struct {
+		MaxConnections int
+		ServerPort     int    `yaml:"server-port" json:"serverPort"`
+		ReadTimeout    string `json:"readTimeout"`
+		WriteTimeout   string `yaml:",omitempty" json:"write_timeout"`
+		IdleTimeout    string `property:"idle" yaml:"idle-timeout"`
+		Secret         string `yaml:"-"`
+		Token          string `json:"-"`
+		Dash           string `json:"-,"`
+		Pool           pool   `yaml:",inline"`
+		Tuning         pool   `json:"tuning"`
+	}{})
+	if err != nil {
+		t.Fatalf("SchemaFromStruct: %v", err)
+	}
+	want := []string{"-", "idle", "maxconnections", "minidle", "readTimeout", "server-port", "tuning", "tuning.minidle", "write_timeout"}
+	if got := s.sortedKeys(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got keys %v, want %v", got, want)
+	}
+}
+
+// structs holding themselves, which SchemaFromStruct can't give keys for
+type listNode struct {
+	Value string
+	Next  *listNode
+}
+
+type treeNode struct {
+	Name     string
+	Children struct{ Parent *treeNode }
+}
+
+func TestSchemaFromStructErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		v    interface{}
+		want string
+	}{
+		{"not a struct", "text", "SchemaFromStruct needs a struct, not string"},
+		{"unsupported type", struct{ Handler func() }{}, "handler: unsupported type func()"},
+		{"bad number", struct {
+			Port int `min:"one"`
+		}{}, `port: min tag "one" is not a number`},
+		{"bad option", struct {
+			Port int `property:"port,optional"`
+		}{}, "port: unknown property tag option optional"},
+		{"bad pattern", struct {
+			Name string `pattern:"["`
+		}{}, "name: "},
+		{"recursive type", listNode{}, "next: recursive type *simpleProperties.listNode"},
+		{"indirectly recursive type", struct{ Tree treeNode }{}, "tree.children.parent: recursive type *simpleProperties.treeNode"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := SchemaFromStruct(tt.v)
+			if err == nil || !strings.HasPrefix(err.Error(), tt.want) {
+				t.Errorf("got %v, want %s", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteJSONSchemaRoundTrip(t *testing.T) {
+	s, err := SchemaFromStruct(exportConfig{})
+	if err != nil {
+		t.Fatalf("SchemaFromStruct: %v", err)
+	}
+	for _, file := range []string{"./testdata/resources/schema/schema.json"} {
+		loaded, err := LoadSchemaFile(file)
+		if err != nil {
+			t.Fatalf("LoadSchemaFile: %v", err)
+		}
+		for name, schema := range map[string]Schema{"struct": s, file: loaded} {
+			out := &bytes.Buffer{}
+			if err := schema.WriteJSONSchema(out); err != nil {
+				t.Fatalf("%s: WriteJSONSchema: %v", name, err)
+			}
+			back, err := LoadSchema(out)
+			if err != nil {
+				t.Fatalf("%s: LoadSchema: %v\n%s", name, err, out)
+			}
+			if !reflect.DeepEqual(back, schema) {
+				for key := range schema {
+					if !reflect.DeepEqual(back[key], schema[key]) {
+						t.Errorf("%s: %s: got %+v, want %+v", name, key, back[key], schema[key])
+					}
+				}
+				t.Errorf("%s: got keys %v, want %v", name, back.sortedKeys(), schema.sortedKeys())
+			}
+		}
+	}
+}
+
+func TestWriteTemplate(t *testing.T) {
+	s := Schema{
+		"server":      newRule(kindObject).Description("the http server"),
+		"server.port": Int().Required().Range(1, 65535).Default("8080").Description("the port to listen on"),
+		"server.mode": String().Enum("dev", "prod"),
+		"name":        String().Pattern("^[a-z]+$"),
+	}
+	tests := []struct {
+		format Format
+		want   string
+	}{
+		{FormatProperties, `# (string, matching ^[a-z]+$)
+name=
+# (string, one of dev, prod)
+server.mode=
+# the port to listen on (integer, required, 1 to 65535)
+server.port=8080
+`},
+		{FormatYAML, `# (string, matching ^[a-z]+$)
+name:
+# the http server
+server:
+  # (string, one of dev, prod)
+  mode:
+  # the port to listen on (integer, required, 1 to 65535)
+  port: 8080
+`},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			out := &bytes.Buffer{}
+			if err := s.WriteTemplate(out, tt.format); err != nil {
+				t.Fatalf("WriteTemplate: %v", err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", out, tt.want)
+			}
+		})
+	}
+	if err := s.WriteTemplate(&bytes.Buffer{}, FormatTOML); err == nil {
+		t.Errorf("expected an error writing a toml template")
+	}
+}
@@ -0,0 +1,125 @@
+package simpleProperties
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func violations(err error) []string {
+	got := []string{}
+	var loadError *LoadError
+	if errors.As(err, &loadError) {
+		for _, e := range loadError.Errors {
+			got = append(got, e.Error())
+		}
+	}
+	return got
+}
+
+func TestSchema(t *testing.T) {
+	schema := Schema{
+		"server.port":    Int().Range(1, 65535).Required(),
+		"server.mode":    String().Enum("dev", "prod"),
+		"server.timeout": Duration(),
+		"server.name":    String().Pattern("^[a-z]+$").Length(2, 5),
+		"db.url":         URL().ExclusiveWith("db.host"),
+		"db.host":        HostPort(),
+		"db.user":        String().Required(),
+		"debug":          Bool(),
+		"ratio":          Float().Max(1),
+		"calculated":     Int(),
+	}
+	p := EmptyProperties()
+	p.SetEvaluators(DefaultEvaluator())
+	p.SetSchema(schema)
+	p.Merge(map[string]string{
+		"server.port":    "70000",
+		"server.mode":    "test",
+		"server.timeout": "1m30s",
+		"server.name":    "Billing-Service",
+		"db.url":         "db.example.com",
+		"db.host":        "db.example.com:5432",
+		"debug":          "yes",
+		"ratio":          "0.5",
+		"calculated":     "${nothing}",
+	})
+	want := []string{
+		`calculated: has unresolved expressions: ${nothing}`,
+		`db.url: cannot be set with db.host`,
+		`db.url: "db.example.com" is not a valid url`,
+		`db.user: is required`,
+		`debug: "yes" is not a valid boolean`,
+		`server.mode: "test" is not one of dev, prod`,
+		`server.name: "Billing-Service" is longer than 5 characters`,
+		`server.name: "Billing-Service" does not match ^[a-z]+$`,
+		`server.port: 70000 is more than 65535`,
+	}
+	err := p.Evaluate()
+	if got := violations(err); !reflect.DeepEqual(got, want) {
+		t.Errorf("Evaluate() violations = %q, want %q", got, want)
+	}
+
+	p.Merge(map[string]string{"server.port": "8080", "server.mode": "prod", "server.name": "bill", "db.user": "sa",
+		"debug": "false", "calculated": "${server.port}"})
+	p.Unset("db.url")
+	if err := p.Evaluate(); err != nil {
+		t.Errorf("Evaluate() error = %v", err)
+	}
+	if err := schema.Validate(p); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+}
+
+func TestLoadSchema(t *testing.T) {
+	schema, err := LoadSchemaFile("testdata/resources/schema/schema.json")
+	if err != nil {
+		t.Fatalf("LoadSchemaFile() error = %v", err)
+	}
+	keys := []string{}
+	for k := range schema {
+		keys = append(keys, k)
+	}
+	if len(keys) != 11 {
+		t.Errorf("LoadSchemaFile() keys = %v", keys)
+	}
+	if d := schema["name"].description; d != "the service name" {
+		t.Errorf("description = %v", d)
+	}
+	if d := schema["server.port"].defaultValue; d == nil || *d != "8080" {
+		t.Errorf("default = %v", d)
+	}
+
+	p := EmptyProperties()
+	p.SetEvaluators(DefaultEvaluator())
+	GlobalPropertyLoader("testdata/resources/schema/application")(p)
+	p.SetSchema(schema)
+	source := "testdata/resources/schema/application.properties: "
+	want := []string{
+		source + `db.host: "db.example.com" is not a valid host:port`,
+		source + `db.pool: 2 is more than 1.5`,
+		source + `db.url: cannot be set with db.host`,
+		source + `name: "ab" is shorter than 3 characters`,
+		source + `server.mode: "test" is not one of dev, prod`,
+		source + `server.port: 70000 is more than 65535`,
+		source + `server.timeout: "ten seconds" is not a valid duration`,
+	}
+	got := violations(p.Evaluate())
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Evaluate() violations =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	// metrics is optional, but needs enabled once any of it is set
+	p.Set("metrics.interval", "10s")
+	if got := violations(p.Evaluate()); !containsString(got, "metrics.enabled: is required") {
+		t.Errorf("Evaluate() violations = %v, want metrics.enabled to be required", got)
+	}
+}
+
+func TestLoadSchemaErrors(t *testing.T) {
+	for _, document := range []string{`{"type": "string"}`, `{"properties": {"a": {"type": "array"}}}`, `{"properties": {"a": {"pattern": "("}}}`, `{`} {
+		if _, err := LoadSchema(strings.NewReader(document)); err == nil {
+			t.Errorf("LoadSchema(%s) no error", document)
+		}
+	}
+}
@@ -1,13 +1,17 @@
 package simpleProperties
 
-import "container/list"
+import (
+	"container/list"
+	"log"
+	"strings"
+)
 
 var internalProperties = &Properties{
-	make(map[string]string, 32),
-	make(map[string]string, 32),
-	make(map[string]string, 32),
-	make(map[string]*list.List, 32),
-	nil}
+	bootKeyValueMap: make(map[string]string, 32),
+	keyValueMap:     make(map[string]string, 32),
+	evalKeyValueMap: make(map[string]string, 32),
+	evalExprMap:     make(map[string]*list.List, 32),
+}
 
 // load simpleProperties with this precedence
 //
@@ -29,42 +33,125 @@ func init() {
 	//	operations = append(operations, LoadOSEnvironment())
 	operations = append(operations, LoadCLIParameters())
 	//
-	// evaluators
-	operations = append(operations, BasicEvaluator())
-	// operations = append(operations, DefaultEvaluator())
-	//
 	internalProperties.operations = operations
+	// evaluators
+	internalProperties.SetEvaluators(DefaultEvaluator())
 	// boot properties
 	f := BootPropertyLoader(bootstrapPath)
 	f(internalProperties)
+	if len(internalProperties.errs) > 0 {
+		log.Fatalf("Invalid bootstrap properties. Error %s", newLoadError(internalProperties.errs))
+	}
 }
 
 // DefaultProperties create a default properties structure. This will contain the bootstrap properties and default operations
 // to load the properties via the default operations, call the Load method
 func DefaultProperties() *Properties {
-	return &Properties{copyKV(internalProperties.bootKeyValueMap),
-		make(map[string]string, 32),
-		make(map[string]string, 32),
-		make(map[string]*list.List, 32),
-		copyOps(internalProperties.operations),
+	return &Properties{
+		bootKeyValueMap: copyKV(internalProperties.bootKeyValueMap),
+		keyValueMap:     make(map[string]string, 32),
+		evalKeyValueMap: make(map[string]string, 32),
+		evalExprMap:     make(map[string]*list.List, 32),
+		rawKeyValueMap:  make(map[string]string, 32),
+		operations:      copyOps(internalProperties.operations),
+		evaluators:      copyOps(internalProperties.evaluators),
 	}
 }
 
 // EmptyProperties create a blank properties structure with no data or operations
 func EmptyProperties() *Properties {
-	return &Properties{make(map[string]string, 32),
-		make(map[string]string, 32),
-		make(map[string]string, 32),
-		make(map[string]*list.List, 32),
-		nil,
+	return &Properties{
+		bootKeyValueMap: make(map[string]string, 32),
+		keyValueMap:     make(map[string]string, 32),
+		evalKeyValueMap: make(map[string]string, 32),
+		evalExprMap:     make(map[string]*list.List, 32),
+		rawKeyValueMap:  make(map[string]string, 32),
 	}
 }
 
-// Load execute the list operations for property loading
-func (p *Properties) Load() {
+// Load execute the list operations for property loading, then evaluate the loaded properties. Any problems
+// found on the way, such as a missing import, are returned together as a *LoadError
+func (p *Properties) Load() error {
 	for _, f := range p.operations {
 		f(p)
 	}
+	return p.Evaluate()
+}
+
+// Evaluate evaluate the properties again, starting from the values as loaded or set rather than the results of
+// any earlier evaluation. Call this after Set, SetDefault, Unset or Merge so that properties depending on a
+// changed value, such as url=${host}:${port}, are recomputed. The result is then checked against any schema set
+// with SetSchema
+func (p *Properties) Evaluate() error {
+	p.keyValueMap = make(map[string]string, len(p.rawKeyValueMap))
+	p.evalKeyValueMap = make(map[string]string)
+	p.evalExprMap = make(map[string]*list.List)
+	p.applyFlags()
+	for k, v := range p.flagDefaults() {
+		if _, set := p.rawKeyValueMap[k]; !set {
+			storeKV(p, k, v)
+		}
+	}
+	for k, v := range p.defaultKeyValueMap {
+		if _, set := p.rawKeyValueMap[k]; !set {
+			storeKV(p, k, v)
+		}
+	}
+	for k, v := range p.rawKeyValueMap {
+		storeKV(p, k, v)
+	}
+	for _, f := range p.evaluators {
+		f(p)
+	}
+	if p.schema != nil {
+		p.errs = append(p.errs, p.schema.violations(p)...)
+	}
+	err := newLoadError(p.errs)
+	p.errs = nil
+	return err
+}
+
+// Set set a property, as if it had been loaded from a file, overriding any loaded value. Properties that
+// depend on it are recomputed by the next call to Evaluate, as are any ${} expressions in the value
+func (p *Properties) Set(key string, value string) {
+	setKV(p, key, value)
+}
+
+// SetDefault set a value used for a property when no file, CLI parameter or call to Set gives it one
+func (p *Properties) SetDefault(key string, value string) {
+	k := p.storedKey(strings.Trim(key, " \t"))
+	v := strings.Trim(value, " \t")
+	if k == "" {
+		return
+	}
+	if p.defaultKeyValueMap == nil {
+		p.defaultKeyValueMap = make(map[string]string)
+	}
+	p.defaultKeyValueMap[k] = v
+	if _, set := p.rawKeyValueMap[k]; !set {
+		storeKV(p, k, v)
+	}
+}
+
+// Unset remove a property, as if it had never been loaded or set. Any default set for it applies again
+func (p *Properties) Unset(key string) {
+	k := p.storedKey(strings.Trim(key, " \t"))
+	delete(p.rawKeyValueMap, k)
+	delete(p.keyValueMap, k)
+	delete(p.evalKeyValueMap, k)
+	delete(p.evalExprMap, k)
+	delete(p.sources, k)
+	delete(p.setAs, k)
+	if v, found := p.defaultKeyValueMap[k]; found {
+		storeKV(p, k, v)
+	}
+}
+
+// Merge set each of the properties in a map, as with Set
+func (p *Properties) Merge(values map[string]string) {
+	for k, v := range values {
+		setKV(p, k, v)
+	}
 }
 
 // GetBootProperty get a bootstrap property (if it exists)
@@ -77,11 +164,13 @@ func (p *Properties) GetBootProperty(key string) string {
 }
 
 // GetProperty get a global property (if it exists). will fall back to boostrap properties if not
-// held in the global property map
+// held in the global property map. an alias finds the property it names, see Alias, as does another spelling of a
+// relaxed key, see SetRelaxedKeys
 func (p *Properties) GetProperty(key string) string {
 	if key == "" {
 		return ""
 	} else {
+		key = p.storedKey(key)
 		if p.keyValueMap != nil {
 			v := p.keyValueMap[key]
 			if v == "" {
@@ -95,6 +184,25 @@ func (p *Properties) GetProperty(key string) string {
 	}
 }
 
+// find a property value in the same way as GetProperty, also reporting whether the property has a value at all.
+// a property set to an empty string has a value, an unresolved expression does not
+func (p *Properties) lookup(key string) (string, bool) {
+	key = p.storedKey(key)
+	v, found := p.keyValueMap[key]
+	if found && v != "" {
+		return v, true
+	}
+	if bv, bootFound := p.bootKeyValueMap[key]; bootFound {
+		return bv, true
+	}
+	return v, found
+}
+
+// name a property for an error message, with the file or other source it came from when known
+func (p *Properties) describeKey(key string) string {
+	return describeSource(p.sources[key], key)
+}
+
 // GetEvalProperty get a value from the map of evaluated properties
 func (p *Properties) GetEvalProperty(key string) string {
 	if key == "" {
@@ -160,9 +268,30 @@ func copyOps(in []func(p *Properties)) []func(p *Properties) {
 }
 
 type Properties struct {
-	bootKeyValueMap map[string]string
-	keyValueMap     map[string]string
-	evalKeyValueMap map[string]string
-	evalExprMap     map[string]*list.List
-	operations      []func(p *Properties)
+	bootKeyValueMap    map[string]string
+	keyValueMap        map[string]string
+	evalKeyValueMap    map[string]string
+	evalExprMap        map[string]*list.List
+	rawKeyValueMap     map[string]string // values as loaded or set, before evaluation
+	defaultKeyValueMap map[string]string // values set with SetDefault
+	sources            map[string]string // where each value in rawKeyValueMap came from, when known
+	operations         []func(p *Properties)
+	evaluators         []func(p *Properties) // run by Evaluate, after the operations
+	formats            []string              // file extensions to load, least to highest precedence. nil for the default order
+	profileKey         string                // property holding the profile names. empty for the default key
+	defaultProfiles    []string              // profiles used when none are set. nil for the default profile
+	setProfiles        []string              // profiles chosen with SetProfiles, overriding any other source
+	profiles           []string              // active profiles, nil until the profile loader has run
+	pending            []func(p *Properties) // conditional content waiting for the active profiles to be known
+	resolvers          map[string]Resolver   // namespaces registered for expressions, such as ${env:HOME}
+	lenientNamespaces  bool                  // read ${a:b} as a default, rather than an unknown namespace
+	schema             Schema                // checked after evaluation, if set
+	aliases            map[string]*alias     // old names for properties, see Alias
+	setAs              map[string]string     // the name each property was last loaded or set with, once there are aliases
+	warned             map[string]bool       // deprecated names already warned about
+	logger             Logger                // where warnings go. nil for the standard logger
+	relaxedKeys        bool                  // store keys in a canonical form, see SetRelaxedKeys
+	relaxedIndex       map[string]relaxedKey // the keys stored with relaxed keys, by their words
+	flagSources        []FlagSource          // command line flags bound to properties, see BindFlagSource
+	errs               []error               // problems found while loading, returned by Load
 }
@@ -13,11 +13,12 @@ func TestNewProperties(t *testing.T) {
 	}{
 		{name: "Test new properties",
 			want: Properties{
-				make(map[string]string),
-				make(map[string]string),
-				make(map[string]string),
-				make(map[string]*list.List),
-				nil},
+				bootKeyValueMap: make(map[string]string),
+				keyValueMap:     make(map[string]string),
+				evalKeyValueMap: make(map[string]string),
+				evalExprMap:     make(map[string]*list.List),
+				rawKeyValueMap:  make(map[string]string),
+			},
 		},
 	}
 	for _, tt := range tests {
@@ -28,3 +29,53 @@ func TestNewProperties(t *testing.T) {
 		})
 	}
 }
+
+func TestSetAndEvaluate(t *testing.T) {
+	p := EmptyProperties()
+	p.evaluators = []func(*Properties){BasicEvaluator()}
+	p.Merge(map[string]string{"host": "localhost", "url": "${host}:${port}"})
+	p.SetDefault("port", "80")
+	if err := p.Evaluate(); err != nil {
+		t.Fatalf("Evaluate() error = %v", err)
+	}
+	steps := []struct {
+		name   string
+		change func()
+		key    string
+		want   string
+	}{
+		{"default used", func() {}, "url", "localhost:80"},
+		{"set overrides default", func() { p.Set("port", "8080") }, "url", "localhost:8080"},
+		{"dependent recomputed", func() { p.Set("host", "example.com") }, "url", "example.com:8080"},
+		{"unset falls back to default", func() { p.Unset("port") }, "url", "example.com:80"},
+		{"default only used when unset", func() { p.Set("port", "1"); p.SetDefault("port", "2") }, "url", "example.com:1"},
+		{"expression replaced by value", func() { p.Set("url", "fixed") }, "url", "fixed"},
+		{"value replaced by expression", func() { p.Set("url", "${host}") }, "url", "example.com"},
+		{"unset without default", func() { p.Unset("host") }, "url", ""},
+	}
+	for _, step := range steps {
+		step.change()
+		if err := p.Evaluate(); err != nil {
+			t.Fatalf("%v: Evaluate() error = %v", step.name, err)
+		}
+		if got := p.GetProperty(step.key); got != step.want {
+			t.Errorf("%v: GetProperty(%v) = %v, want %v", step.name, step.key, got, step.want)
+		}
+	}
+}
+
+func TestSetBeforeEvaluate(t *testing.T) {
+	p := EmptyProperties()
+	p.Set(" key ", " value ")
+	p.SetDefault("other", "default")
+	if got := p.GetProperty("key"); got != "value" {
+		t.Errorf("GetProperty(key) = %v, want %v", got, "value")
+	}
+	if got := p.GetProperty("other"); got != "default" {
+		t.Errorf("GetProperty(other) = %v, want %v", got, "default")
+	}
+	p.Set("", "ignored")
+	if len(p.GetKeys()) != 2 {
+		t.Errorf("GetKeys() = %v, want 2 keys", p.GetKeys())
+	}
+}
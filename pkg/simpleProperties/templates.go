@@ -0,0 +1,313 @@
+package simpleProperties
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// TemplateEvaluator an evaluator that renders property values holding {{ }} as Go text/template templates. The data
+// is every property, nested by the dots in its key, so server.host is {{ .server.host }}. A key that isn't a valid
+// template identifier, such as max-pool, is read with {{ index . "max-pool" }}
+//
+//	url={{ .server.host }}:{{ .server.port | default 80 }}
+//
+// The functions of the template package are joined by a set in the style of sprig: default, required, coalesce,
+// empty, ternary, upper, lower, trim, trimPrefix, trimSuffix, replace, contains, hasPrefix, hasSuffix, split, join,
+// quote, squote, env, toJson, b64enc, b64dec, int, add, sub, mul, div and mod. Further functions can be given, and
+// replace any of the same name
+//
+// A template using another templated property is rendered after it. A missing key renders as <no value>, unless
+// replaced with default or checked with required. Problems, such as a template that can't be parsed, are returned
+// by Load with their position in the template, and leave the value as loaded
+//
+// Templates are rendered from the values that ${} expressions have been resolved to, so list TemplateEvaluator after
+// DefaultEvaluator to use both
+func TemplateEvaluator(funcs ...template.FuncMap) Evaluator {
+	all := template.FuncMap{}
+	for name, f := range templateFuncs {
+		all[name] = f
+	}
+	for _, extra := range funcs {
+		for name, f := range extra {
+			all[name] = f
+		}
+	}
+	return EvaluatorFunc(func(c EvaluationContext) {
+		r := &templateRenderer{
+			c:         c,
+			funcs:     all,
+			data:      make(map[string]interface{}),
+			templates: make(map[string]*template.Template),
+			state:     make(map[string]int),
+		}
+		r.render()
+	})
+}
+
+// state for rendering the templated properties
+type templateRenderer struct {
+	c         EvaluationContext
+	funcs     template.FuncMap
+	data      map[string]interface{} // all property values, nested
+	keys      []string               // the templated properties, in sorted order
+	templates map[string]*template.Template
+	state     map[string]int
+}
+
+func (r *templateRenderer) render() {
+	values := make(map[string]string)
+	for _, key := range r.c.Keys() {
+		v, found := r.c.Lookup(key)
+		if !found {
+			continue
+		}
+		values[key] = v
+		if !strings.Contains(v, "{{") {
+			continue
+		}
+		t, err := template.New(key).Funcs(r.funcs).Option("missingkey=zero").Parse(v)
+		if err != nil {
+			r.c.Fail(key, err)
+			continue
+		}
+		r.keys = append(r.keys, key)
+		r.templates[key] = t
+	}
+	r.data = nestValues(values)
+	for _, key := range r.keys {
+		r.resolve(key, nil)
+	}
+}
+
+// render a template once the templates it uses have been rendered. path holds the templates being rendered that
+// led here, for reporting cycles
+func (r *templateRenderer) resolve(key string, path []string) {
+	switch r.state[key] {
+	case visited:
+		return
+	case visiting:
+		for i, name := range path {
+			if name == key {
+				cycle := append(append([]string{}, path[i:]...), key)
+				r.c.Fail("", fmt.Errorf("template cycle %s", strings.Join(cycle, " -> ")))
+			}
+		}
+		return
+	}
+	r.state[key] = visiting
+	path = append(path, key)
+	t := r.templates[key]
+	for _, name := range templateNames(t.Tree.Root) {
+		for _, used := range r.keys {
+			if used == name || strings.HasPrefix(used, name+".") {
+				r.resolve(used, path)
+			}
+		}
+	}
+	rendered := strings.Builder{}
+	if err := t.Execute(&rendered, r.data); err != nil {
+		r.c.Fail(key, err)
+	} else {
+		r.c.Resolve(key, rendered.String())
+		setNestedValue(r.data, key, rendered.String())
+	}
+	r.state[key] = visited
+}
+
+// the property names a template may use: the fields it reads, such as server.host for {{ .server.host }}, and the
+// strings it holds, for {{ index . "max-pool" }}
+func templateNames(node parse.Node) []string {
+	names := []string{}
+	var walk func(node parse.Node)
+	branch := func(b *parse.BranchNode) {
+		walk(b.Pipe)
+		walk(b.List)
+		walk(b.ElseList)
+	}
+	walk = func(node parse.Node) {
+		switch n := node.(type) {
+		case *parse.ListNode:
+			if n != nil {
+				for _, child := range n.Nodes {
+					walk(child)
+				}
+			}
+		case *parse.ActionNode:
+			walk(n.Pipe)
+		case *parse.PipeNode:
+			if n != nil {
+				for _, command := range n.Cmds {
+					walk(command)
+				}
+			}
+		case *parse.CommandNode:
+			for _, argument := range n.Args {
+				walk(argument)
+			}
+		case *parse.ChainNode:
+			walk(n.Node)
+		case *parse.FieldNode:
+			names = append(names, strings.Join(n.Ident, "."))
+		case *parse.StringNode:
+			names = append(names, n.Text)
+		case *parse.IfNode:
+			branch(&n.BranchNode)
+		case *parse.RangeNode:
+			branch(&n.BranchNode)
+		case *parse.WithNode:
+			branch(&n.BranchNode)
+		case *parse.TemplateNode:
+			walk(n.Pipe)
+		}
+	}
+	walk(node)
+	sort.Strings(names)
+	return names
+}
+
+// functions for templates, in the style of sprig, so pipelines read {{ .port | default 80 }}
+var templateFuncs = template.FuncMap{
+	"default": func(fallback interface{}, v ...interface{}) interface{} {
+		if len(v) == 0 || templateEmpty(v[0]) {
+			return fallback
+		}
+		return v[0]
+	},
+	"required": func(message string, v interface{}) (interface{}, error) {
+		if templateEmpty(v) {
+			return nil, fmt.Errorf("%s", message)
+		}
+		return v, nil
+	},
+	"coalesce": func(v ...interface{}) interface{} {
+		for _, value := range v {
+			if !templateEmpty(value) {
+				return value
+			}
+		}
+		return nil
+	},
+	"empty": templateEmpty,
+	"ternary": func(whenTrue interface{}, whenFalse interface{}, condition bool) interface{} {
+		if condition {
+			return whenTrue
+		}
+		return whenFalse
+	},
+	"upper":      func(s interface{}) string { return strings.ToUpper(templateString(s)) },
+	"lower":      func(s interface{}) string { return strings.ToLower(templateString(s)) },
+	"trim":       func(s interface{}) string { return strings.TrimSpace(templateString(s)) },
+	"trimPrefix": func(prefix string, s interface{}) string { return strings.TrimPrefix(templateString(s), prefix) },
+	"trimSuffix": func(suffix string, s interface{}) string { return strings.TrimSuffix(templateString(s), suffix) },
+	"replace": func(old string, new string, s interface{}) string {
+		return strings.ReplaceAll(templateString(s), old, new)
+	},
+	"contains":  func(substr string, s interface{}) bool { return strings.Contains(templateString(s), substr) },
+	"hasPrefix": func(prefix string, s interface{}) bool { return strings.HasPrefix(templateString(s), prefix) },
+	"hasSuffix": func(suffix string, s interface{}) bool { return strings.HasSuffix(templateString(s), suffix) },
+	"split":     func(separator string, s interface{}) []string { return strings.Split(templateString(s), separator) },
+	"join": func(separator string, list interface{}) string {
+		v := reflect.ValueOf(list)
+		if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+			return templateString(list)
+		}
+		parts := make([]string, v.Len())
+		for i := range parts {
+			parts[i] = templateString(v.Index(i).Interface())
+		}
+		return strings.Join(parts, separator)
+	},
+	"quote":  func(s interface{}) string { return strconv.Quote(templateString(s)) },
+	"squote": func(s interface{}) string { return "'" + templateString(s) + "'" },
+	"env":    os.Getenv,
+	"toJson": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"b64enc": func(s interface{}) string { return base64.StdEncoding.EncodeToString([]byte(templateString(s))) },
+	"b64dec": func(s interface{}) (string, error) {
+		b, err := base64.StdEncoding.DecodeString(templateString(s))
+		return string(b), err
+	},
+	"int": templateInt,
+	"add": func(a interface{}, b interface{}) (int64, error) {
+		return templateArithmetic(a, b, func(x, y int64) (int64, error) { return x + y, nil })
+	},
+	"sub": func(a interface{}, b interface{}) (int64, error) {
+		return templateArithmetic(a, b, func(x, y int64) (int64, error) { return x - y, nil })
+	},
+	"mul": func(a interface{}, b interface{}) (int64, error) {
+		return templateArithmetic(a, b, func(x, y int64) (int64, error) { return x * y, nil })
+	},
+	"div": func(a interface{}, b interface{}) (int64, error) {
+		return templateArithmetic(a, b, func(x, y int64) (int64, error) {
+			if y == 0 {
+				return 0, fmt.Errorf("division by zero")
+			}
+			return x / y, nil
+		})
+	},
+	"mod": func(a interface{}, b interface{}) (int64, error) {
+		return templateArithmetic(a, b, func(x, y int64) (int64, error) {
+			if y == 0 {
+				return 0, fmt.Errorf("division by zero")
+			}
+			return x % y, nil
+		})
+	},
+}
+
+// a value is empty if it is missing, an empty string, or the zero value of its type
+func templateEmpty(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	value := reflect.ValueOf(v)
+	switch value.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array, reflect.String:
+		return value.Len() == 0
+	}
+	return value.IsZero()
+}
+
+func templateString(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+// a whole number from a template value, which is usually the text of a property
+func templateInt(v interface{}) (int64, error) {
+	switch n := v.(type) {
+	case int:
+		return int64(n), nil
+	case int64:
+		return n, nil
+	}
+	i, err := strconv.ParseInt(strings.TrimSpace(templateString(v)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a whole number", templateString(v))
+	}
+	return i, nil
+}
+
+func templateArithmetic(a interface{}, b interface{}, f func(int64, int64) (int64, error)) (int64, error) {
+	x, err := templateInt(a)
+	if err != nil {
+		return 0, err
+	}
+	y, err := templateInt(b)
+	if err != nil {
+		return 0, err
+	}
+	return f(x, y)
+}
@@ -0,0 +1,81 @@
+package simpleProperties
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func TestTemplateEvaluator(t *testing.T) {
+	p := EmptyProperties()
+	p.bootKeyValueMap["application.name"] = "billing"
+	p.SetEvaluators(DefaultEvaluator(), TemplateEvaluator(template.FuncMap{"twice": func(s string) string { return s + s }}))
+	p.Merge(map[string]string{
+		"server.host":   "example.com",
+		"server.scheme": "${tls ? https : http}",
+		"tls":           "true",
+		"max-pool":      "8",
+		"url":           "{{ .server.scheme }}://{{ .server.host }}:{{ .server.port | default 80 }}",
+		"endpoint":      "{{ .url }}/{{ index . \"application\" \"name\" }}",
+		"dsn":           "{{ with .db }}{{ .user }}@{{ .host }}{{ else }}none{{ end }}",
+		"pool":          "{{ index . \"max-pool\" | mul 4 }}",
+		"json":          "{{ toJson .server }}",
+		"funcs":         "{{ .server.host | upper | replace \".\" \"-\" | quote }} {{ twice \"ab\" }} {{ split \",\" \"a,b\" | join \"+\" }}",
+		"plain":         "no template here",
+	})
+	if err := p.Evaluate(); err != nil {
+		t.Fatalf("Evaluate() error = %v", err)
+	}
+	want := map[string]string{
+		"url":      "https://example.com:80",
+		"endpoint": "https://example.com:80/billing",
+		"dsn":      "none",
+		"pool":     "32",
+		"json":     `{"host":"example.com","scheme":"https"}`,
+		"funcs":    `"EXAMPLE-COM" abab a+b`,
+		"plain":    "no template here",
+	}
+	for k, v := range want {
+		if got := p.GetProperty(k); got != v {
+			t.Errorf("GetProperty(%s) = %v, want %v", k, got, v)
+		}
+	}
+	if got := p.GetRaw("url"); !strings.HasPrefix(got, "{{ .server.scheme }}") {
+		t.Errorf("GetRaw(url) = %v", got)
+	}
+}
+
+func TestTemplateEvaluatorErrors(t *testing.T) {
+	p := EmptyProperties()
+	p.SetEvaluators(TemplateEvaluator())
+	p.Merge(map[string]string{
+		"parse":    "ok {{ .a ",
+		"func":     "{{ nothing .a }}",
+		"required": "{{ required \"port is needed\" .port }}",
+		"cycle.a":  "{{ .cycle.b }}",
+		"cycle.b":  "{{ .cycle.a }}",
+	})
+	err := p.Evaluate()
+	var loadError *LoadError
+	if !errors.As(err, &loadError) {
+		t.Fatalf("Evaluate() error = %v, want a *LoadError", err)
+	}
+	want := []string{
+		`func: template: func:1: function "nothing" not defined`,
+		"parse: template: parse:1: unclosed action",
+		"template cycle cycle.a -> cycle.b -> cycle.a",
+		`required: template: required:1:3: executing "required" at <required "port is needed" .port>: error calling required: port is needed`,
+	}
+	if len(loadError.Errors) != len(want) {
+		t.Fatalf("Evaluate() errors = %v, want %v", loadError.Errors, want)
+	}
+	for i, e := range loadError.Errors {
+		if e.Error() != want[i] {
+			t.Errorf("Evaluate() error %d = %v, want %v", i, e, want[i])
+		}
+	}
+	if got := p.GetProperty("parse"); got != "ok {{ .a" {
+		t.Errorf("GetProperty(parse) = %v, want the value as loaded", got)
+	}
+}
@@ -0,0 +1,367 @@
+package simpleProperties
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Format an output format for WriteFormat
+type Format string
+
+const (
+	FormatProperties Format = "properties" // key=value lines, escaped so they load back unchanged
+	FormatYAML       Format = "yaml"       // nested yaml, rebuilt from the dotted keys
+	FormatFlatYAML   Format = "flat-yaml"  // yaml with one dotted key per line
+	FormatJSON       Format = "json"       // nested json, rebuilt from the dotted keys
+	FormatTOML       Format = "toml"       // toml, with a table for each level of the dotted keys
+	FormatEnv        Format = "env"        // KEY=value lines for a shell or .env file
+)
+
+const redacted = "******"
+
+// keys containing any of these, ignoring case, are redacted by RedactSensitive
+var sensitiveKeys = []string{"password", "passwd", "secret", "token", "credential", "private", "apikey", "api-key", "api_key"}
+
+// whether a key looks like it holds a secret, as RedactSensitive judges it
+func sensitiveKey(key string) bool {
+	lower := strings.ToLower(key)
+	for _, s := range sensitiveKeys {
+		if strings.Contains(lower, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteOption an option for WriteFormat
+type WriteOption func(*writeOptions)
+
+type writeOptions struct {
+	raw    bool
+	redact []string
+}
+
+// Raw write values as loaded, keeping any ${} expressions, rather than as evaluated
+func Raw() WriteOption {
+	return func(o *writeOptions) {
+		o.raw = true
+	}
+}
+
+// Resolved write values as evaluated. This is the default
+func Resolved() WriteOption {
+	return func(o *writeOptions) {
+		o.raw = false
+	}
+}
+
+// Redact replace the value of any key containing one of the given strings, ignoring case, with ******
+func Redact(keys ...string) WriteOption {
+	return func(o *writeOptions) {
+		for _, key := range keys {
+			o.redact = append(o.redact, strings.ToLower(key))
+		}
+	}
+}
+
+// RedactSensitive redact keys that look like they hold a secret, such as db.password or api.token
+func RedactSensitive() WriteOption {
+	return Redact(sensitiveKeys...)
+}
+
+// WriteFormat write the properties out in the given format. Boot properties are included, overridden by
+// application properties of the same name as with GetProperty. Keys are written in sorted order so the
+// output is stable, and expressions that could not be resolved are written as they stand.
+//
+// Files written as .properties, .yaml, .json and flat yaml load back to the same keys and values. When
+// nesting a key that is also the parent of other keys, e.g. a=1 and a.b=2, the child is written with a
+// dotted name at the level of its parent, {"a": "1", "a.b": "2"}, which loads back the same way.
+func (p *Properties) WriteFormat(w io.Writer, format Format, options ...WriteOption) error {
+	o := &writeOptions{}
+	for _, option := range options {
+		option(o)
+	}
+	values := p.writeValues(o)
+	keys := sortedKeys(values)
+	buffer := bufio.NewWriter(w)
+	var err error
+	switch format {
+	case FormatProperties:
+		for _, key := range keys {
+			fmt.Fprintf(buffer, "%s=%s\n", escapeProperty(key, true), escapeProperty(values[key], false))
+		}
+	case FormatFlatYAML:
+		root := &yaml.Node{Kind: yaml.MappingNode}
+		for _, key := range keys {
+			root.Content = append(root.Content, yamlScalar(key), yamlScalar(values[key]))
+		}
+		err = writeYAML(buffer, root)
+	case FormatYAML:
+		err = writeYAML(buffer, yamlTree(nestValues(values)))
+	case FormatJSON:
+		encoder := json.NewEncoder(buffer)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(nestValues(values))
+	case FormatTOML:
+		writeTOML(buffer, nestValues(values), nil)
+	case FormatEnv:
+		for _, key := range keys {
+			fmt.Fprintf(buffer, "%s=%s\n", envName(key), shellQuote(values[key]))
+		}
+	default:
+		return fmt.Errorf("unknown output format %s", format)
+	}
+	if err != nil {
+		return err
+	}
+	return buffer.Flush()
+}
+
+// gather the values to write
+func (p *Properties) writeValues(o *writeOptions) map[string]string {
+	values := copyKV(p.bootKeyValueMap)
+	if o.raw {
+		for k, v := range p.defaultKeyValueMap {
+			values[k] = v
+		}
+		for k, v := range p.rawKeyValueMap {
+			values[k] = v
+		}
+	} else {
+		for k, v := range p.keyValueMap {
+			if v != "" || values[k] == "" {
+				values[k] = v
+			}
+		}
+		for k, v := range p.evalKeyValueMap {
+			if values[k] == "" {
+				values[k] = v
+			}
+		}
+	}
+	for k := range values {
+		lower := strings.ToLower(k)
+		for _, r := range o.redact {
+			if strings.Contains(lower, r) {
+				values[k] = redacted
+				break
+			}
+		}
+	}
+	return values
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// rebuild a tree of nested maps from dotted keys. a value is either a string or a map[string]interface{}.
+// keys are added in sorted order, so a parent key is always seen before its children
+func nestValues(values map[string]string) map[string]interface{} {
+	root := make(map[string]interface{})
+	for _, key := range sortedKeys(values) {
+		setNestedValue(root, key, values[key])
+	}
+	return root
+}
+
+// put one dotted key into a tree of nested maps, as nestValues does. setting the keys in sorted order gives the
+// same tree whatever order the keys are held in
+func setNestedValue(root map[string]interface{}, key string, value string) {
+	parts := strings.Split(key, ".")
+	level := root
+	for i, part := range parts {
+		if part == "" {
+			level[strings.Join(parts[i:], ".")] = value // odd key, e.g. a..b, keep the rest as it is
+			return
+		}
+		if i == len(parts)-1 {
+			level[part] = value
+			return
+		}
+		child, found := level[part]
+		if !found {
+			child = make(map[string]interface{})
+			level[part] = child
+		}
+		childMap, isMap := child.(map[string]interface{})
+		if !isMap {
+			level[strings.Join(parts[i:], ".")] = value // part already holds a value
+			return
+		}
+		level = childMap
+	}
+}
+
+func writeYAML(w io.Writer, root *yaml.Node) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(root); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// build a yaml mapping node from a tree of nested maps
+func yamlTree(tree map[string]interface{}) *yaml.Node {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	keys := make([]string, 0, len(tree))
+	for k := range tree {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		switch v := tree[k].(type) {
+		case string:
+			node.Content = append(node.Content, yamlScalar(k), yamlScalar(v))
+		case map[string]interface{}:
+			node.Content = append(node.Content, yamlScalar(k), yamlTree(v))
+		}
+	}
+	return node
+}
+
+// a scalar written plain where yaml allows, as values load back as written whatever type yaml sees them as.
+// text that would load as null is forced to be a string
+func yamlScalar(value string) *yaml.Node {
+	node := &yaml.Node{Kind: yaml.ScalarNode, Value: value}
+	switch strings.ToLower(value) {
+	case "null", "~":
+		node.Tag = "!!str"
+	}
+	return node
+}
+
+// write a toml document. values at each level come first, then a table for each nested map
+func writeTOML(w io.Writer, tree map[string]interface{}, path []string) {
+	keys := make([]string, 0, len(tree))
+	for k := range tree {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if v, isString := tree[k].(string); isString {
+			fmt.Fprintf(w, "%s = %s\n", tomlKey(k), tomlString(v))
+		}
+	}
+	for _, k := range keys {
+		if v, isMap := tree[k].(map[string]interface{}); isMap {
+			table := append(append([]string{}, path...), tomlKey(k))
+			fmt.Fprintf(w, "\n[%s]\n", strings.Join(table, "."))
+			writeTOML(w, v, table)
+		}
+	}
+}
+
+// a toml key, quoted unless it is a bare key
+func tomlKey(key string) string {
+	for _, c := range key {
+		if !(c == '_' || c == '-' || c <= unicode.MaxASCII && (unicode.IsLetter(c) || unicode.IsDigit(c))) {
+			return tomlString(key)
+		}
+	}
+	if key == "" {
+		return `""`
+	}
+	return key
+}
+
+// a toml basic string
+func tomlString(value string) string {
+	b := strings.Builder{}
+	b.WriteByte('"')
+	for _, c := range value {
+		switch c {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if unicode.IsControl(c) {
+				fmt.Fprintf(&b, `\u%04X`, c)
+			} else {
+				b.WriteRune(c)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// an environment variable name for a key, e.g. server.max-connections becomes SERVER_MAX_CONNECTIONS
+func envName(key string) string {
+	b := strings.Builder{}
+	for _, c := range key {
+		if c <= unicode.MaxASCII && (unicode.IsLetter(c) || unicode.IsDigit(c)) {
+			b.WriteRune(unicode.ToUpper(c))
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
+
+// quote a value for a shell, unless it only holds characters that are safe as they are
+func shellQuote(value string) string {
+	for _, c := range value {
+		if !(c <= unicode.MaxASCII && (unicode.IsLetter(c) || unicode.IsDigit(c)) || strings.ContainsRune("_-./:@%+,", c)) {
+			return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+		}
+	}
+	return value
+}
+
+// escape a key or value for a .properties file. see unescapeProperty
+func escapeProperty(s string, key bool) string {
+	b := strings.Builder{}
+	for i, c := range s {
+		switch c {
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '=':
+			if key {
+				b.WriteString(`\=`)
+			} else {
+				b.WriteRune(c)
+			}
+		case '#', '!':
+			if key && i == 0 {
+				b.WriteRune('\\') // would otherwise start a comment
+			}
+			b.WriteRune(c)
+		default:
+			if unicode.IsControl(c) {
+				fmt.Fprintf(&b, `\u%04X`, c)
+			} else {
+				b.WriteRune(c)
+			}
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,186 @@
+package simpleProperties
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// values that need care in one format or another
+func writerTestProperties() *Properties {
+	p := EmptyProperties()
+	p.bootKeyValueMap["boot"] = "from boot"
+	p.bootKeyValueMap["overridden"] = "from boot"
+	p.keyValueMap = map[string]string{
+		"overridden":      "from application",
+		"server.port":     "8080",
+		"server.host":     "localhost",
+		"server":          "leaf and parent",
+		"db.password":     "hunter2",
+		"equals":          "a=b=c",
+		"key=with#equals": "value",
+		"#comment":        "not a comment",
+		"path":            `c:\work\new`,
+		"multi":           "line one\nline two",
+		"quote":           `it's "quoted"`,
+		"null":            "null",
+		"empty":           "",
+		"unicode":         "héllo wörld",
+		"yaml.special":    "- not: a list",
+	}
+	p.evalKeyValueMap["greeting"] = "Hello ${name}"
+	return p
+}
+
+func TestWriteFormatRoundTrip(t *testing.T) {
+	tests := []struct {
+		format    Format
+		extension string
+	}{
+		{FormatProperties, ".properties"},
+		{FormatYAML, ".yaml"},
+		{FormatFlatYAML, ".yaml"},
+		{FormatJSON, ".json"},
+	}
+	p := writerTestProperties()
+	want := p.writeValues(&writeOptions{})
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			buffer := &bytes.Buffer{}
+			if err := p.WriteFormat(buffer, tt.format); err != nil {
+				t.Fatalf("WriteFormat() error = %v", err)
+			}
+			path := filepath.Join(t.TempDir(), "written")
+			if err := os.WriteFile(path+tt.extension, buffer.Bytes(), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			loaded := EmptyProperties()
+			GlobalPropertyLoader(path)(loaded)
+			got := copyKV(loaded.keyValueMap)
+			for k, v := range loaded.evalKeyValueMap {
+				got[k] = v
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("WriteFormat() loaded back as %v, want %v\n%s", got, want, buffer.String())
+			}
+		})
+	}
+}
+
+func TestWriteFormat(t *testing.T) {
+	p := EmptyProperties()
+	p.keyValueMap = map[string]string{
+		"server.port":        "8080",
+		"server.max-conn":    "10",
+		"server.ssl.enabled": "true",
+		"app.name":           `my "app"`,
+		"db.password":        "hunter2",
+		"top":                "it's",
+	}
+	p.evalKeyValueMap["url"] = "http://${server.host}"
+	tests := []struct {
+		name    string
+		format  Format
+		options []WriteOption
+		want    string
+	}{
+		{"properties",
+			FormatProperties, nil,
+			"app.name=my \"app\"\ndb.password=hunter2\nserver.max-conn=10\nserver.port=8080\nserver.ssl.enabled=true\ntop=it's\nurl=http://${server.host}\n",
+		},
+		{"redacted",
+			FormatProperties, []WriteOption{RedactSensitive(), Redact("TOP")},
+			"app.name=my \"app\"\ndb.password=******\nserver.max-conn=10\nserver.port=8080\nserver.ssl.enabled=true\ntop=******\nurl=http://${server.host}\n",
+		},
+		{"yaml",
+			FormatYAML, nil,
+			"app:\n  name: my \"app\"\ndb:\n  password: hunter2\nserver:\n  max-conn: 10\n  port: 8080\n  ssl:\n    enabled: true\ntop: it's\nurl: http://${server.host}\n",
+		},
+		{"flat yaml",
+			FormatFlatYAML, nil,
+			"app.name: my \"app\"\ndb.password: hunter2\nserver.max-conn: 10\nserver.port: 8080\nserver.ssl.enabled: true\ntop: it's\nurl: http://${server.host}\n",
+		},
+		{"json",
+			FormatJSON, nil,
+			"{\n  \"app\": {\n    \"name\": \"my \\\"app\\\"\"\n  },\n  \"db\": {\n    \"password\": \"hunter2\"\n  },\n  \"server\": {\n    \"max-conn\": \"10\",\n    \"port\": \"8080\",\n    \"ssl\": {\n      \"enabled\": \"true\"\n    }\n  },\n  \"top\": \"it's\",\n  \"url\": \"http://${server.host}\"\n}\n",
+		},
+		{"toml",
+			FormatTOML, nil,
+			"top = \"it's\"\nurl = \"http://${server.host}\"\n\n[app]\nname = \"my \\\"app\\\"\"\n\n[db]\npassword = \"hunter2\"\n\n[server]\nmax-conn = \"10\"\nport = \"8080\"\n\n[server.ssl]\nenabled = \"true\"\n",
+		},
+		{"env",
+			FormatEnv, nil,
+			"APP_NAME='my \"app\"'\nDB_PASSWORD=hunter2\nSERVER_MAX_CONN=10\nSERVER_PORT=8080\nSERVER_SSL_ENABLED=true\nTOP='it'\\''s'\nURL='http://${server.host}'\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buffer := &bytes.Buffer{}
+			if err := p.WriteFormat(buffer, tt.format, tt.options...); err != nil {
+				t.Fatalf("WriteFormat() error = %v", err)
+			}
+			if got := buffer.String(); got != tt.want {
+				t.Errorf("WriteFormat() = \n%v\nwant\n%v", got, tt.want)
+			}
+		})
+	}
+	if err := p.WriteFormat(&bytes.Buffer{}, Format("xml")); err == nil {
+		t.Errorf("WriteFormat() expected an error for an unknown format")
+	}
+}
+
+func TestWriteFormatRaw(t *testing.T) {
+	p := EmptyProperties()
+	p.evaluators = []func(*Properties){BasicEvaluator()}
+	p.Set("name", "World")
+	p.Set("greeting", "Hello ${name}")
+	p.Set("pending", "${unknown}")
+	p.SetDefault("colour", "blue")
+	if err := p.Evaluate(); err != nil {
+		t.Fatalf("Evaluate() error = %v", err)
+	}
+	tests := []struct {
+		name    string
+		options []WriteOption
+		want    string
+	}{
+		{"resolved", nil, "colour=blue\ngreeting=Hello World\nname=World\npending=${unknown}\n"},
+		{"raw", []WriteOption{Raw()}, "colour=blue\ngreeting=Hello ${name}\nname=World\npending=${unknown}\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buffer := &bytes.Buffer{}
+			if err := p.WriteFormat(buffer, FormatProperties, tt.options...); err != nil {
+				t.Fatalf("WriteFormat() error = %v", err)
+			}
+			if got := buffer.String(); got != tt.want {
+				t.Errorf("WriteFormat() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_unescapeProperty(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{`plain`, `plain`},
+		{`a\=b`, `a=b`},
+		{`tab\there`, "tab\there"},
+		{`new\nline`, "new\nline"},
+		{`\u00e9t\u00E9`, "été"},
+		{`\uZZZZ`, `\uZZZZ`},
+		{`c:\work`, `c:\work`},
+		{`back\\slash`, `back\slash`},
+		{`\#hash \! \:`, `#hash ! :`},
+		{`trailing\`, `trailing\`},
+	}
+	for _, tt := range tests {
+		if got := unescapeProperty(tt.in); got != tt.want {
+			t.Errorf("unescapeProperty(%v) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}